@@ -0,0 +1,179 @@
+package addrbook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/lib"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	MaxReputation = 100
+	MinReputation = -100
+)
+
+// PeerRecord represents a peer known to the node
+type PeerRecord struct {
+	ID            peer.ID   `json:"id"`
+	Addrs         []string  `json:"addrs"`
+	LastConnected time.Time `json:"last_connected"`
+	Reputation    int       `json:"reputation"`
+}
+
+// AddressBook keeps track of peers seen by the node and persists them on disk,
+// so the node can reconnect to known peers after restart.
+type AddressBook struct {
+	path  string
+	mutex sync.Mutex
+	peers map[peer.ID]*PeerRecord
+}
+
+func NewAddressBook(path string) (*AddressBook, error) {
+	ab := &AddressBook{
+		path:  path,
+		peers: map[peer.ID]*PeerRecord{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ab, nil
+		}
+		return nil, xerrors.Errorf("failed to read address book: %w", err)
+	}
+
+	var records []*PeerRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, xerrors.Errorf("failed to decode address book: %w", err)
+	}
+	for _, r := range records {
+		ab.peers[r.ID] = r
+	}
+
+	return ab, nil
+}
+
+// AddPeer adds the peer to the address book or updates its addresses
+func (ab *AddressBook) AddPeer(info peer.AddrInfo) {
+	if len(info.Addrs) == 0 {
+		return
+	}
+	addrs := make([]string, 0, len(info.Addrs))
+	for _, a := range info.Addrs {
+		addrs = append(addrs, a.String())
+	}
+
+	ab.mutex.Lock()
+	defer ab.mutex.Unlock()
+	r, ok := ab.peers[info.ID]
+	if !ok {
+		r = &PeerRecord{ID: info.ID}
+		ab.peers[info.ID] = r
+	}
+	r.Addrs = addrs
+}
+
+// MarkConnected updates last connection time and raises the reputation of the peer
+func (ab *AddressBook) MarkConnected(id peer.ID) {
+	ab.mutex.Lock()
+	defer ab.mutex.Unlock()
+	r, ok := ab.peers[id]
+	if !ok {
+		return
+	}
+	r.LastConnected = time.Now()
+	r.Reputation = clampReputation(r.Reputation + 1)
+}
+
+// MarkFailed lowers the reputation of the peer after unsuccessful dial
+func (ab *AddressBook) MarkFailed(id peer.ID) {
+	ab.mutex.Lock()
+	defer ab.mutex.Unlock()
+	r, ok := ab.peers[id]
+	if !ok {
+		return
+	}
+	r.Reputation = clampReputation(r.Reputation - 1)
+}
+
+func (ab *AddressBook) RemovePeer(id peer.ID) {
+	ab.mutex.Lock()
+	defer ab.mutex.Unlock()
+	delete(ab.peers, id)
+}
+
+// Peers returns known peers ordered by reputation and recency of last connection
+func (ab *AddressBook) Peers() []peer.AddrInfo {
+	// records are copied, since they are updated by MarkConnected and MarkFailed after unlock.
+	// Addresses are replaced rather than modified, so they can be shared.
+	ab.mutex.Lock()
+	records := make([]PeerRecord, 0, len(ab.peers))
+	for _, r := range ab.peers {
+		records = append(records, *r)
+	}
+	ab.mutex.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Reputation != records[j].Reputation {
+			return records[i].Reputation > records[j].Reputation
+		}
+		return records[i].LastConnected.After(records[j].LastConnected)
+	})
+
+	var result []peer.AddrInfo
+	for _, r := range records {
+		info := peer.AddrInfo{ID: r.ID}
+		for _, a := range r.Addrs {
+			maddr, err := multiaddr.NewMultiaddr(a)
+			if err != nil {
+				logrus.Warnf("Invalid multiaddress %s of peer %s in address book: %v", a, r.ID, err)
+				continue
+			}
+			info.Addrs = append(info.Addrs, maddr)
+		}
+		if len(info.Addrs) == 0 {
+			continue
+		}
+		result = append(result, info)
+	}
+
+	return result
+}
+
+// Save writes the address book to disk
+func (ab *AddressBook) Save() error {
+	ab.mutex.Lock()
+	records := make([]*PeerRecord, 0, len(ab.peers))
+	for _, r := range ab.peers {
+		records = append(records, r)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	ab.mutex.Unlock()
+	if err != nil {
+		return xerrors.Errorf("failed to encode address book: %w", err)
+	}
+
+	if err := lib.WriteFileAtomic(ab.path, data, 0600); err != nil {
+		return xerrors.Errorf("failed to write address book: %w", err)
+	}
+
+	return nil
+}
+
+func clampReputation(r int) int {
+	if r > MaxReputation {
+		return MaxReputation
+	}
+	if r < MinReputation {
+		return MinReputation
+	}
+	return r
+}
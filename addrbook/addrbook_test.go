@@ -0,0 +1,69 @@
+package addrbook
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/test"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressBookPersistence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "peers.json")
+
+	ab, err := NewAddressBook(path)
+	assert.NoError(t, err)
+
+	good, err := test.RandPeerID()
+	assert.NoError(t, err)
+	bad, err := test.RandPeerID()
+	assert.NoError(t, err)
+	ab.AddPeer(peer.AddrInfo{ID: bad, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/4001")}})
+	ab.AddPeer(peer.AddrInfo{ID: good, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/4002")}})
+	ab.MarkConnected(good)
+	ab.MarkFailed(bad)
+	assert.NoError(t, ab.Save())
+
+	loaded, err := NewAddressBook(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	peers := loaded.Peers()
+	assert.Len(t, peers, 2)
+	assert.Equal(t, good, peers[0].ID)
+	assert.Equal(t, "/ip4/127.0.0.1/tcp/4002", peers[0].Addrs[0].String())
+	assert.Equal(t, bad, peers[1].ID)
+}
+
+func TestAddressBookConcurrentUpdates(t *testing.T) {
+	ab, err := NewAddressBook(filepath.Join(t.TempDir(), "peers.json"))
+	assert.NoError(t, err)
+	id, err := test.RandPeerID()
+	assert.NoError(t, err)
+	other, err := test.RandPeerID()
+	assert.NoError(t, err)
+	ab.AddPeer(peer.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/4001")}})
+	ab.AddPeer(peer.AddrInfo{ID: other, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/4002")}})
+
+	// peers are listed while their records are updated, run with -race
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			ab.MarkConnected(id)
+			ab.MarkFailed(id)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.Len(t, ab.Peers(), 2)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Secured-Finance/dione/banlist"
+	"github.com/Secured-Finance/dione/config"
+	"github.com/Secured-Finance/dione/deadletter"
+	"github.com/Secured-Finance/dione/msgstore"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/xerrors"
+)
+
+const DefaultListenAddr = config.DefaultAdminListenAddr
+
+// Status is the summary of node state
+type Status struct {
+	PeerID      string `json:"peer_id"`
+	Mode        string `json:"mode"`
+	Peers       int    `json:"peers"`
+	LastBlock   uint64 `json:"last_block"`
+	DeadLetters int    `json:"dead_letters"`
+	LogLevel    string `json:"log_level"`
+}
+
+// Backend is the node operations available through the admin API
+type Backend interface {
+	Status() *Status
+	ConnectPeer(ctx context.Context, addr string) (string, error)
+	DisconnectPeer(peerID string) error
+	Resync(ctx context.Context, fromBlock uint64) error
+	DeadLetters() ([]*deadletter.Entry, error)
+	RequeueDeadLetter(ctx context.Context, requestID string) error
+	PruneDeadLetters(olderThan time.Duration) (int, error)
+	ConsensusMessages(consensusID string) ([]*msgstore.Record, error)
+	Banlist() *banlist.List
+	BanPeer(peerID, reason string) error
+	UnbanPeer(peerID string) error
+	ImportBanlist(source string) (int, error)
+	RotateIdentityKey() (string, error)
+}
+
+// Server serves the admin API. It listens on its own address and requires bearer token,
+// so it can't be reached through the interfaces serving public queries.
+type Server struct {
+	backend    Backend
+	listenAddr string
+	token      []byte
+	ctx        context.Context
+}
+
+func NewServer(backend Backend, cfg *config.AdminConfig) (*Server, error) {
+	if cfg.Token == "" {
+		return nil, xerrors.Errorf("admin api requires token")
+	}
+	s := &Server{
+		backend:    backend,
+		listenAddr: cfg.ListenAddr,
+		token:      []byte(cfg.Token),
+		ctx:        context.Background(),
+	}
+	if s.listenAddr == "" {
+		s.listenAddr = DefaultListenAddr
+	}
+	return s, nil
+}
+
+// Serve starts serving admin requests, it blocks until ctx is done
+func (s *Server) Serve(ctx context.Context) error {
+	s.ctx = ctx
+	srv := &fasthttp.Server{Handler: s.handle}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(); err != nil {
+			logrus.Errorf("Failed to shutdown admin api: %v", err)
+		}
+	}()
+	logrus.Infof("Admin API is listening on %s", s.listenAddr)
+	return srv.ListenAndServe(s.listenAddr)
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+type peerRequest struct {
+	Addr   string `json:"addr"`
+	PeerID string `json:"peer_id"`
+	Reason string `json:"reason"`
+}
+
+type banlistImportRequest struct {
+	Source string `json:"source"` // path of the file on the node host or http(s) URL
+}
+
+type resyncRequest struct {
+	FromBlock uint64 `json:"from_block"`
+}
+
+type deadLetterRequest struct {
+	RequestID string `json:"request_id"`
+	OlderThan string `json:"older_than"` // duration, e.g. 720h
+}
+
+func (s *Server) handle(ctx *fasthttp.RequestCtx) {
+	if !s.authenticate(ctx) {
+		ctx.Error("unauthorized", fasthttp.StatusUnauthorized)
+		return
+	}
+
+	path := string(ctx.Path())
+	if ctx.IsGet() {
+		switch path {
+		case "/admin/status":
+			writeJSON(ctx, s.backend.Status())
+		case "/admin/dead-letters":
+			entries, err := s.backend.DeadLetters()
+			if err != nil {
+				ctx.Error(err.Error(), fasthttp.StatusConflict)
+				return
+			}
+			writeJSON(ctx, entries)
+		case "/admin/consensus-messages":
+			consensusID := string(ctx.QueryArgs().Peek("consensus_id"))
+			if consensusID == "" {
+				ctx.Error("consensus_id is required", fasthttp.StatusBadRequest)
+				return
+			}
+			records, err := s.backend.ConsensusMessages(consensusID)
+			if err != nil {
+				ctx.Error(err.Error(), fasthttp.StatusConflict)
+				return
+			}
+			writeJSON(ctx, records)
+		case "/admin/banlist":
+			writeJSON(ctx, s.backend.Banlist())
+		default:
+			ctx.Error("not found", fasthttp.StatusNotFound)
+		}
+		return
+	}
+	if !ctx.IsPost() {
+		ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	var result interface{}
+	switch path {
+	case "/admin/log-level":
+		var req logLevelRequest
+		if err = decode(ctx, &req); err == nil {
+			err = setLogLevel(req.Level)
+		}
+	case "/admin/peers/connect":
+		var req peerRequest
+		if err = decode(ctx, &req); err == nil {
+			var id string
+			id, err = s.backend.ConnectPeer(s.ctx, req.Addr)
+			result = map[string]string{"peer_id": id}
+		}
+	case "/admin/peers/disconnect":
+		var req peerRequest
+		if err = decode(ctx, &req); err == nil {
+			err = s.backend.DisconnectPeer(req.PeerID)
+		}
+	case "/admin/banlist/ban":
+		var req peerRequest
+		if err = decode(ctx, &req); err == nil {
+			err = s.backend.BanPeer(req.PeerID, req.Reason)
+		}
+	case "/admin/banlist/unban":
+		var req peerRequest
+		if err = decode(ctx, &req); err == nil {
+			err = s.backend.UnbanPeer(req.PeerID)
+		}
+	case "/admin/banlist/import":
+		var req banlistImportRequest
+		if err = decode(ctx, &req); err == nil {
+			var banned int
+			banned, err = s.backend.ImportBanlist(req.Source)
+			result = map[string]int{"banned": banned}
+		}
+	case "/admin/keys/rotate":
+		var id string
+		if id, err = s.backend.RotateIdentityKey(); err == nil {
+			result = map[string]string{"peer_id": id}
+		}
+	case "/admin/resync":
+		var req resyncRequest
+		if err = decode(ctx, &req); err == nil {
+			err = s.backend.Resync(s.ctx, req.FromBlock)
+		}
+	case "/admin/dead-letters/requeue":
+		var req deadLetterRequest
+		if err = decode(ctx, &req); err == nil {
+			err = s.backend.RequeueDeadLetter(s.ctx, req.RequestID)
+		}
+	case "/admin/dead-letters/prune":
+		var req deadLetterRequest
+		if err = decode(ctx, &req); err == nil {
+			var olderThan time.Duration
+			if olderThan, err = time.ParseDuration(req.OlderThan); err == nil {
+				var pruned int
+				pruned, err = s.backend.PruneDeadLetters(olderThan)
+				result = map[string]int{"pruned": pruned}
+			}
+		}
+	default:
+		ctx.Error("not found", fasthttp.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Warnf("Admin request %s has failed: %v", path, err)
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
+	logrus.Infof("Admin request %s has been done", path)
+	if result == nil {
+		result = map[string]bool{"ok": true}
+	}
+	writeJSON(ctx, result)
+}
+
+func (s *Server) authenticate(ctx *fasthttp.RequestCtx) bool {
+	auth := string(ctx.Request.Header.Peek("Authorization"))
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if token == "" || token == auth {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), s.token) == 1
+}
+
+func setLogLevel(level string) error {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(l)
+	return nil
+}
+
+func decode(ctx *fasthttp.RequestCtx, v interface{}) error {
+	if err := json.Unmarshal(ctx.PostBody(), v); err != nil {
+		return xerrors.Errorf("malformed request: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(ctx *fasthttp.RequestCtx, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
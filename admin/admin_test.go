@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/banlist"
+	"github.com/Secured-Finance/dione/config"
+	"github.com/Secured-Finance/dione/deadletter"
+	"github.com/Secured-Finance/dione/msgstore"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type testBackend struct {
+	requeued string
+	banned   string
+}
+
+func (b *testBackend) Status() *Status { return &Status{PeerID: "peer"} }
+func (b *testBackend) ConnectPeer(ctx context.Context, addr string) (string, error) {
+	return "peer", nil
+}
+func (b *testBackend) DisconnectPeer(peerID string) error                    { return nil }
+func (b *testBackend) Resync(ctx context.Context, fromBlock uint64) error    { return nil }
+func (b *testBackend) DeadLetters() ([]*deadletter.Entry, error)             { return nil, nil }
+func (b *testBackend) PruneDeadLetters(olderThan time.Duration) (int, error) { return 3, nil }
+func (b *testBackend) ConsensusMessages(id string) ([]*msgstore.Record, error) {
+	return []*msgstore.Record{{ConsensusID: id, Data: []byte("msg")}}, nil
+}
+func (b *testBackend) Banlist() *banlist.List                   { return &banlist.List{} }
+func (b *testBackend) BanPeer(peerID, reason string) error      { b.banned = peerID; return nil }
+func (b *testBackend) UnbanPeer(peerID string) error            { return nil }
+func (b *testBackend) ImportBanlist(source string) (int, error) { return 2, nil }
+func (b *testBackend) RotateIdentityKey() (string, error)       { return "new peer", nil }
+func (b *testBackend) RequeueDeadLetter(ctx context.Context, id string) error {
+	b.requeued = id
+	return nil
+}
+
+func doRequest(s *Server, method, path, token, body string) *fasthttp.RequestCtx {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	if token != "" {
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	ctx.Request.SetBodyString(body)
+	s.handle(&ctx)
+	return &ctx
+}
+
+func TestAdminServer(t *testing.T) {
+	_, err := NewServer(&testBackend{}, &config.AdminConfig{})
+	assert.Error(t, err)
+
+	backend := &testBackend{}
+	s, err := NewServer(backend, &config.AdminConfig{Token: "secret"})
+	assert.NoError(t, err)
+
+	ctx := doRequest(s, "GET", "/admin/status", "", "")
+	assert.Equal(t, fasthttp.StatusUnauthorized, ctx.Response.StatusCode())
+	ctx = doRequest(s, "GET", "/admin/status", "wrong", "")
+	assert.Equal(t, fasthttp.StatusUnauthorized, ctx.Response.StatusCode())
+
+	ctx = doRequest(s, "GET", "/admin/status", "secret", "")
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	var status Status
+	assert.NoError(t, json.Unmarshal(ctx.Response.Body(), &status))
+	assert.Equal(t, "peer", status.PeerID)
+
+	level := logrus.GetLevel()
+	defer logrus.SetLevel(level)
+	ctx = doRequest(s, "POST", "/admin/log-level", "secret", `{"level":"warn"}`)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	ctx = doRequest(s, "POST", "/admin/log-level", "secret", `{"level":"loud"}`)
+	assert.Equal(t, fasthttp.StatusBadRequest, ctx.Response.StatusCode())
+
+	ctx = doRequest(s, "POST", "/admin/dead-letters/requeue", "secret", `{"request_id":"42"}`)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, "42", backend.requeued)
+
+	ctx = doRequest(s, "POST", "/admin/dead-letters/prune", "secret", `{"older_than":"720h"}`)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.JSONEq(t, `{"pruned":3}`, string(ctx.Response.Body()))
+
+	ctx = doRequest(s, "GET", "/admin/consensus-messages?consensus_id=42", "secret", "")
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.Contains(t, string(ctx.Response.Body()), `"consensus_id":"42"`)
+	ctx = doRequest(s, "GET", "/admin/consensus-messages", "secret", "")
+	assert.Equal(t, fasthttp.StatusBadRequest, ctx.Response.StatusCode())
+
+	ctx = doRequest(s, "POST", "/admin/banlist/ban", "secret", `{"peer_id":"peer","reason":"spam"}`)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, "peer", backend.banned)
+	ctx = doRequest(s, "POST", "/admin/banlist/import", "secret", `{"source":"https://example.com/banlist.json"}`)
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.JSONEq(t, `{"banned":2}`, string(ctx.Response.Body()))
+
+	ctx = doRequest(s, "POST", "/admin/keys/rotate", "secret", "")
+	assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+	assert.JSONEq(t, `{"peer_id":"new peer"}`, string(ctx.Response.Body()))
+
+	ctx = doRequest(s, "POST", "/admin/unknown", "secret", `{}`)
+	assert.Equal(t, fasthttp.StatusNotFound, ctx.Response.StatusCode())
+}
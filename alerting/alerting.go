@@ -0,0 +1,193 @@
+package alerting
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/xerrors"
+)
+
+const (
+	AlertDataSourceDown   = "data_source_down"
+	AlertSubmissionFailed = "submission_failed"
+	AlertNodeIsolated     = "node_isolated"
+	AlertConsensusFailed  = "consensus_failed"
+
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+
+	DefaultFailureThreshold = 3
+	DefaultCooldown         = 10 * time.Minute
+
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	requestTimeout     = 10 * time.Second
+)
+
+// Alert is the notification about critical condition of the node
+type Alert struct {
+	Name     string    `json:"name"`
+	Key      string    `json:"key,omitempty"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+	Source   string    `json:"source"`
+	Time     time.Time `json:"time"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary   string `json:"summary"`
+	Source    string `json:"source"`
+	Severity  string `json:"severity"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Alerter sends alerts to configured webhooks and PagerDuty.
+// Conditions are counted per key and alert fires when count of consecutive failures reaches the threshold,
+// the same alert of the same key isn't fired again until cooldown passes.
+type Alerter struct {
+	webhooks         []string
+	pagerDutyKey     string
+	failureThreshold int
+	cooldown         time.Duration
+	source           string
+	httpClient       *fasthttp.Client
+
+	mutex     sync.Mutex
+	failures  map[string]int
+	lastFired map[string]time.Time
+}
+
+func NewAlerter(cfg *config.AlertingConfig) *Alerter {
+	source, err := os.Hostname()
+	if err != nil {
+		source = "dione"
+	}
+	a := &Alerter{
+		webhooks:         cfg.Webhooks,
+		pagerDutyKey:     cfg.PagerDutyRoutingKey,
+		failureThreshold: cfg.FailureThreshold,
+		cooldown:         time.Duration(cfg.Cooldown) * time.Second,
+		source:           source,
+		httpClient:       &fasthttp.Client{},
+		failures:         map[string]int{},
+		lastFired:        map[string]time.Time{},
+	}
+	if a.failureThreshold <= 0 {
+		a.failureThreshold = DefaultFailureThreshold
+	}
+	if a.cooldown <= 0 {
+		a.cooldown = DefaultCooldown
+	}
+	return a
+}
+
+// ReportFailure counts the failure of condition with specified key and fires the alert once the threshold is reached
+func (a *Alerter) ReportFailure(name, key, message string) {
+	if a == nil {
+		return
+	}
+	a.mutex.Lock()
+	id := name + ":" + key
+	a.failures[id]++
+	reached := a.failures[id] >= a.failureThreshold
+	a.mutex.Unlock()
+
+	if reached {
+		a.Fire(name, key, SeverityCritical, message)
+	}
+}
+
+// ReportSuccess resets the failure counter of condition with specified key
+func (a *Alerter) ReportSuccess(name, key string) {
+	if a == nil {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.failures, name+":"+key)
+}
+
+// Fire sends the alert about condition with specified key to all destinations asynchronously
+func (a *Alerter) Fire(name, key, severity, message string) {
+	if a == nil {
+		return
+	}
+	id := name + ":" + key
+	a.mutex.Lock()
+	if last, ok := a.lastFired[id]; ok && time.Since(last) < a.cooldown {
+		a.mutex.Unlock()
+		return
+	}
+	a.lastFired[id] = time.Now()
+	a.mutex.Unlock()
+
+	alert := &Alert{
+		Name:     name,
+		Key:      key,
+		Severity: severity,
+		Message:  message,
+		Source:   a.source,
+		Time:     time.Now(),
+	}
+	logrus.Warnf("Firing alert %s (%s): %s", name, key, message)
+	go a.send(alert)
+}
+
+func (a *Alerter) send(alert *Alert) {
+	for _, url := range a.webhooks {
+		if err := a.post(url, alert); err != nil {
+			logrus.Errorf("Failed to send alert to webhook %s: %v", url, err)
+		}
+	}
+	if a.pagerDutyKey != "" {
+		event := &pagerDutyEvent{
+			RoutingKey:  a.pagerDutyKey,
+			EventAction: "trigger",
+			DedupKey:    alert.Name + ":" + alert.Key,
+			Payload: pagerDutyPayload{
+				Summary:   alert.Message,
+				Source:    alert.Source,
+				Severity:  alert.Severity,
+				Timestamp: alert.Time.Format(time.RFC3339),
+			},
+		}
+		if err := a.post(pagerDutyEventsURL, event); err != nil {
+			logrus.Errorf("Failed to send alert to PagerDuty: %v", err)
+		}
+	}
+}
+
+func (a *Alerter) post(url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod("POST")
+	req.Header.SetContentType("application/json")
+	req.SetBody(body)
+	if err := a.httpClient.DoTimeout(req, resp, requestTimeout); err != nil {
+		return err
+	}
+	if resp.StatusCode() >= 300 {
+		return xerrors.Errorf("unexpected response status %d", resp.StatusCode())
+	}
+	return nil
+}
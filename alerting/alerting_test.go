@@ -0,0 +1,76 @@
+package alerting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlerterThreshold(t *testing.T) {
+	alerts := make(chan Alert, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err == nil {
+			alerts <- a
+		}
+	}))
+	defer srv.Close()
+
+	a := NewAlerter(&config.AlertingConfig{
+		Webhooks:         []string{srv.URL},
+		FailureThreshold: 2,
+	})
+
+	a.ReportFailure(AlertDataSourceDown, "0/getTransaction", "down")
+	a.ReportSuccess(AlertDataSourceDown, "0/getTransaction")
+	a.ReportFailure(AlertDataSourceDown, "0/getTransaction", "down")
+	assert.Len(t, alerts, 0)
+
+	a.ReportFailure(AlertDataSourceDown, "0/getTransaction", "down")
+	select {
+	case alert := <-alerts:
+		assert.Equal(t, AlertDataSourceDown, alert.Name)
+		assert.Equal(t, SeverityCritical, alert.Severity)
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert wasn't sent")
+	}
+
+	// the same alert is suppressed during cooldown
+	a.ReportFailure(AlertDataSourceDown, "0/getTransaction", "down")
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, alerts, 0)
+}
+
+func TestAlerterCooldownPerKey(t *testing.T) {
+	alerts := make(chan Alert, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err == nil {
+			alerts <- a
+		}
+	}))
+	defer srv.Close()
+
+	a := NewAlerter(&config.AlertingConfig{Webhooks: []string{srv.URL}})
+	a.Fire(AlertDataSourceDown, "0/getTransaction", SeverityCritical, "down")
+	a.Fire(AlertDataSourceDown, "2/getBlock", SeverityCritical, "down")
+	a.Fire(AlertDataSourceDown, "2/getBlock", SeverityCritical, "down")
+
+	keys := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case alert := <-alerts:
+			keys[alert.Key] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("alert wasn't sent")
+		}
+	}
+	assert.Equal(t, map[string]bool{"0/getTransaction": true, "2/getBlock": true}, keys)
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, alerts, 0)
+}
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	KindProposal     = "proposal"
+	KindPrepare      = "prepare"
+	KindCommit       = "commit"
+	KindSubmission   = "submission"
+	KindDisputeBegin = "dispute_begin"
+	KindDisputeVote  = "dispute_vote"
+)
+
+// Record is an entry of the audit log. Every record contains the hash of previous one,
+// so any modification or removal of records in the middle of the log breaks the chain.
+type Record struct {
+	Seq         uint64            `json:"seq"`
+	Time        time.Time         `json:"time"`
+	Kind        string            `json:"kind"`
+	Context     map[string]string `json:"context,omitempty"`
+	PayloadHash string            `json:"payload_hash"`
+	Signature   string            `json:"signature,omitempty"`
+	PrevHash    string            `json:"prev_hash"`
+	Hash        string            `json:"hash"`
+}
+
+func (r *Record) computeHash() (string, error) {
+	c := *r
+	c.Hash = ""
+	data, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Log is an append-only, hash-chained log of everything the node has signed
+type Log struct {
+	mutex    sync.Mutex
+	file     *os.File
+	seq      uint64
+	lastHash string
+}
+
+// Open opens the audit log at path, verifying the existing records before appending new ones
+func Open(path string) (*Log, error) {
+	l := &Log{}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open audit log: %w", err)
+	}
+	last, err := Verify(f)
+	if err != nil {
+		f.Close()
+		return nil, xerrors.Errorf("audit log %s is corrupted: %w", path, err)
+	}
+	if last != nil {
+		l.seq = last.Seq
+		l.lastHash = last.Hash
+	}
+	l.file = f
+
+	return l, nil
+}
+
+// Append adds the record about signed payload to the log
+func (l *Log) Append(kind string, payload []byte, signature []byte, context map[string]string) error {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	payloadHash := sha256.Sum256(payload)
+	r := &Record{
+		Seq:         l.seq + 1,
+		Time:        time.Now().UTC(),
+		Kind:        kind,
+		Context:     context,
+		PayloadHash: hex.EncodeToString(payloadHash[:]),
+		Signature:   hex.EncodeToString(signature),
+		PrevHash:    l.lastHash,
+	}
+	hash, err := r.computeHash()
+	if err != nil {
+		return xerrors.Errorf("failed to hash audit record: %w", err)
+	}
+	r.Hash = hash
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return xerrors.Errorf("failed to encode audit record: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return xerrors.Errorf("failed to write audit record: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return xerrors.Errorf("failed to sync audit log: %w", err)
+	}
+
+	l.seq = r.Seq
+	l.lastHash = r.Hash
+	return nil
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Verify reads the whole log and checks hashes and sequence numbers of all records.
+// It returns the last record of the log or nil if the log is empty.
+func Verify(r io.Reader) (*Record, error) {
+	var last *Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, xerrors.Errorf("malformed record after seq %d: %w", seqOf(last), err)
+		}
+		if rec.Seq != seqOf(last)+1 {
+			return nil, xerrors.Errorf("record %d follows record %d", rec.Seq, seqOf(last))
+		}
+		prevHash := ""
+		if last != nil {
+			prevHash = last.Hash
+		}
+		if rec.PrevHash != prevHash {
+			return nil, xerrors.Errorf("record %d doesn't link to the previous record", rec.Seq)
+		}
+		hash, err := rec.computeHash()
+		if err != nil {
+			return nil, err
+		}
+		if hash != rec.Hash {
+			return nil, xerrors.Errorf("record %d has invalid hash", rec.Seq)
+		}
+		last = &rec
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read audit log: %w", err)
+	}
+	return last, nil
+}
+
+func seqOf(r *Record) uint64 {
+	if r == nil {
+		return 0
+	}
+	return r.Seq
+}
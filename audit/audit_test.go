@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogChain(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+
+	l, err := Open(path)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Append(KindProposal, []byte("payload"), []byte{1, 2}, map[string]string{"request_id": "1"}))
+	assert.NoError(t, l.Append(KindPrepare, []byte("payload"), nil, map[string]string{"request_id": "1"}))
+	assert.NoError(t, l.Close())
+
+	// appending continues the existing chain
+	l, err = Open(path)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Append(KindCommit, []byte("payload"), nil, nil))
+	assert.NoError(t, l.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	last, err := Verify(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), last.Seq)
+
+	tampered := bytes.Replace(data, []byte(`"kind":"prepare"`), []byte(`"kind":"commit"`), 1)
+	_, err = Verify(bytes.NewReader(tampered))
+	assert.Error(t, err)
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	_, err = Verify(bytes.NewReader(append(append([]byte{}, lines[0]...), lines[2]...)))
+	assert.Error(t, err)
+}
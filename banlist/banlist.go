@@ -0,0 +1,211 @@
+package banlist
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/lib"
+	"github.com/libp2p/go-libp2p-core/connmgr"
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/xerrors"
+)
+
+// SourceLocal is the source of peers banned by the operator of the node
+const SourceLocal = "local"
+
+// Entry represents the banned peer
+type Entry struct {
+	ID       peer.ID   `json:"id"`
+	Reason   string    `json:"reason,omitempty"`
+	BannedAt time.Time `json:"banned_at"`
+	Source   string    `json:"source,omitempty"` // "local", imported file or feed URL
+}
+
+// Banlist keeps peers the node refuses to connect to and persists them on disk.
+// It's used as connection gater of libp2p host, so banned peers are rejected
+// before any protocol is negotiated with them.
+type Banlist struct {
+	path     string
+	trusted  []peer.ID // publishers whose lists can be imported
+	mutex    sync.RWMutex
+	entries  map[peer.ID]*Entry
+	imported map[string]time.Time // publisher -> issue time of the last imported list
+}
+
+// banlistState is the banlist as it's persisted on disk
+type banlistState struct {
+	Entries  []*Entry             `json:"entries"`
+	Imported map[string]time.Time `json:"imported,omitempty"`
+}
+
+var _ connmgr.ConnectionGater = (*Banlist)(nil)
+
+func NewBanlist(path string, trusted []peer.ID) (*Banlist, error) {
+	b := &Banlist{
+		path:     path,
+		trusted:  trusted,
+		entries:  map[peer.ID]*Entry{},
+		imported: map[string]time.Time{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, xerrors.Errorf("failed to read banlist: %w", err)
+	}
+	var state banlistState
+	if err := json.Unmarshal(data, &state); err != nil {
+		// banlists saved by earlier versions are plain lists of entries
+		if err := json.Unmarshal(data, &state.Entries); err != nil {
+			return nil, xerrors.Errorf("failed to decode banlist: %w", err)
+		}
+	}
+	for _, e := range state.Entries {
+		b.entries[e.ID] = e
+	}
+	for publisher, issuedAt := range state.Imported {
+		b.imported[publisher] = issuedAt
+	}
+	return b, nil
+}
+
+// Ban adds the peer to the banlist, local bans take precedence over imported ones
+func (b *Banlist) Ban(id peer.ID, reason string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries[id] = &Entry{ID: id, Reason: reason, BannedAt: time.Now(), Source: SourceLocal}
+	return b.save()
+}
+
+// Unban removes the peer from the banlist regardless of its source
+func (b *Banlist) Unban(id peer.ID) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.entries[id]; !ok {
+		return xerrors.Errorf("peer %s isn't banned", id)
+	}
+	delete(b.entries, id)
+	return b.save()
+}
+
+// IsBanned reports whether the node must not connect to the peer
+func (b *Banlist) IsBanned(id peer.ID) bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	_, ok := b.entries[id]
+	return ok
+}
+
+// List returns all banned peers, the most recent bans go first
+func (b *Banlist) List() []*Entry {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.list()
+}
+
+// Export returns the unsigned list of all banned peers, it can be signed and shared with other nodes
+func (b *Banlist) Export() *List {
+	return &List{Entries: b.List()}
+}
+
+// Import replaces peers previously imported from the source with entries of the list
+// and returns IDs of newly banned peers. Signature of the list must be verified by the caller.
+// Signed lists older than the last imported list of the same publisher are rejected.
+// Local bans are never replaced by imported entries.
+func (b *Banlist) Import(list *List, source string) ([]peer.ID, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the publisher and issue time of unsigned list can't be trusted
+	if len(list.Signature) != 0 {
+		publisher := list.Publisher.Pretty()
+		if last, ok := b.imported[publisher]; ok && list.IssuedAt.Before(last) {
+			return nil, xerrors.Errorf("banlist of publisher %s issued at %s is older than the last imported one issued at %s",
+				publisher, list.IssuedAt.Format(time.RFC3339), last.Format(time.RFC3339))
+		}
+		b.imported[publisher] = list.IssuedAt
+	}
+
+	banned := make(map[peer.ID]struct{}, len(b.entries))
+	for id, e := range b.entries {
+		banned[id] = struct{}{}
+		if e.Source == source {
+			delete(b.entries, id)
+		}
+	}
+	var added []peer.ID
+	for _, e := range list.Entries {
+		if existing, ok := b.entries[e.ID]; ok && existing.Source == SourceLocal {
+			continue
+		}
+		c := *e
+		c.Source = source
+		if c.BannedAt.IsZero() {
+			c.BannedAt = time.Now()
+		}
+		b.entries[c.ID] = &c
+		if _, ok := banned[c.ID]; !ok {
+			added = append(added, c.ID)
+		}
+	}
+	return added, b.save()
+}
+
+func (b *Banlist) InterceptPeerDial(p peer.ID) bool {
+	return !b.IsBanned(p)
+}
+
+func (b *Banlist) InterceptAddrDial(p peer.ID, _ ma.Multiaddr) bool {
+	return !b.IsBanned(p)
+}
+
+func (b *Banlist) InterceptAccept(network.ConnMultiaddrs) bool {
+	// peer ID of inbound connection is known only after the handshake
+	return true
+}
+
+func (b *Banlist) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	return !b.IsBanned(p)
+}
+
+func (b *Banlist) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+func (b *Banlist) list() []*Entry {
+	entries := make([]*Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		c := *e
+		entries = append(entries, &c)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].BannedAt.Equal(entries[j].BannedAt) {
+			return entries[i].BannedAt.After(entries[j].BannedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+func (b *Banlist) save() error {
+	data, err := json.MarshalIndent(&banlistState{Entries: b.list(), Imported: b.imported}, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to encode banlist: %w", err)
+	}
+	if err := lib.WriteFileAtomic(b.path, data, 0600); err != nil {
+		return xerrors.Errorf("failed to write banlist: %w", err)
+	}
+	return nil
+}
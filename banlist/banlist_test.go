@@ -0,0 +1,145 @@
+package banlist
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBanlistImportExport(t *testing.T) {
+	dir := t.TempDir()
+
+	publisherKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.NoError(t, err)
+	publisher, err := peer.IDFromPrivateKey(publisherKey)
+	assert.NoError(t, err)
+	local, _ := test.RandPeerID()
+	shared, _ := test.RandPeerID()
+
+	// the publisher exports and signs its banlist
+	src, err := NewBanlist(filepath.Join(dir, "src.json"), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, src.Ban(shared, "spam"))
+	list := src.Export()
+	assert.NoError(t, list.Sign(publisherKey))
+	data, err := json.Marshal(list)
+	assert.NoError(t, err)
+	listPath := filepath.Join(dir, "signed.json")
+	assert.NoError(t, ioutil.WriteFile(listPath, data, 0600))
+
+	path := filepath.Join(dir, "banlist.json")
+	b, err := NewBanlist(path, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, b.Ban(local, "flood"))
+	assert.False(t, b.InterceptPeerDial(local))
+
+	// signed list of untrusted publisher is rejected
+	_, err = b.ImportFrom(listPath)
+	assert.Error(t, err)
+
+	b, err = NewBanlist(path, []peer.ID{publisher})
+	assert.NoError(t, err)
+	assert.True(t, b.IsBanned(local))
+	added, err := b.ImportFrom(listPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []peer.ID{shared}, added)
+	assert.True(t, b.IsBanned(shared))
+	assert.False(t, b.InterceptSecured(0, shared, nil))
+
+	// already banned peers aren't reported again
+	added, err = b.ImportFrom(listPath)
+	assert.NoError(t, err)
+	assert.Empty(t, added)
+
+	// tampered list fails verification
+	list.Entries = append(list.Entries, &Entry{ID: local})
+	assert.Error(t, list.Verify([]peer.ID{publisher}))
+
+	// reimport of the source replaces its entries, local bans stay
+	added, err = b.Import(&List{}, listPath)
+	assert.NoError(t, err)
+	assert.Empty(t, added)
+	assert.False(t, b.IsBanned(shared))
+	assert.True(t, b.IsBanned(local))
+	assert.NoError(t, b.Unban(local))
+	assert.Error(t, b.Unban(local))
+	assert.Empty(t, b.List())
+}
+
+func TestBanlistReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	publisherKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.NoError(t, err)
+	publisher, err := peer.IDFromPrivateKey(publisherKey)
+	assert.NoError(t, err)
+	banned, _ := test.RandPeerID()
+
+	older := &List{}
+	assert.NoError(t, older.Sign(publisherKey))
+	newer := &List{Entries: []*Entry{{ID: banned}}}
+	assert.NoError(t, newer.Sign(publisherKey))
+	assert.NoError(t, newer.Verify([]peer.ID{publisher}))
+
+	// the issue time is signed
+	issuedAt := newer.IssuedAt
+	newer.IssuedAt = issuedAt.Add(time.Minute)
+	assert.Error(t, newer.Verify([]peer.ID{publisher}))
+	newer.IssuedAt = issuedAt
+
+	path := filepath.Join(dir, "banlist.json")
+	b, err := NewBanlist(path, []peer.ID{publisher})
+	assert.NoError(t, err)
+	_, err = b.Import(newer, "feed")
+	assert.NoError(t, err)
+
+	// the older list without the ban can't be replayed, even after restart
+	older.IssuedAt = issuedAt.Add(-time.Minute)
+	b, err = NewBanlist(path, []peer.ID{publisher})
+	assert.NoError(t, err)
+	_, err = b.Import(older, "feed")
+	assert.Error(t, err)
+	assert.True(t, b.IsBanned(banned))
+	_, err = b.Import(newer, "feed")
+	assert.NoError(t, err)
+
+	// unsigned list naming the publisher doesn't move its last issue time
+	forged := &List{Publisher: publisher, IssuedAt: issuedAt.Add(time.Hour)}
+	_, err = b.Import(forged, "forged")
+	assert.NoError(t, err)
+	_, err = b.Import(newer, "feed")
+	assert.NoError(t, err)
+	data, err := json.Marshal(forged)
+	assert.NoError(t, err)
+	forgedPath := filepath.Join(dir, "forged.json")
+	assert.NoError(t, ioutil.WriteFile(forgedPath, data, 0600))
+	_, err = b.ImportFrom(forgedPath)
+	assert.Error(t, err)
+
+	// lists signed without issue time are rejected
+	unsigned := &List{Publisher: publisher, Signature: []byte{1}}
+	assert.Error(t, unsigned.Verify([]peer.ID{publisher}))
+
+	_, err = Load("http://example.com/banlist.json")
+	assert.Error(t, err)
+}
+
+func TestBanlistLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banlist.json")
+	banned, _ := test.RandPeerID()
+	data, err := json.Marshal([]*Entry{{ID: banned, Source: SourceLocal}})
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	b, err := NewBanlist(path, nil)
+	assert.NoError(t, err)
+	assert.True(t, b.IsBanned(banned))
+}
@@ -0,0 +1,206 @@
+package banlist
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultRefreshInterval = time.Hour
+
+	maxListSize    = 4 << 20
+	requestTimeout = 30 * time.Second
+)
+
+// List is the shareable list of banned peers. It's signed by libp2p key of the publisher,
+// so nodes import only lists of publishers they trust. The issue time is signed too,
+// so an older list of the publisher can't be replayed to unban peers banned by a newer one.
+type List struct {
+	Entries   []*Entry  `json:"entries"`
+	Publisher peer.ID   `json:"publisher,omitempty"`
+	IssuedAt  time.Time `json:"issued_at,omitempty"`
+	Signature []byte    `json:"signature,omitempty"`
+}
+
+func (l *List) signingBytes() ([]byte, error) {
+	return json.Marshal(&List{Entries: l.Entries, Publisher: l.Publisher, IssuedAt: l.IssuedAt})
+}
+
+// Sign signs the list with specified key, its peer ID becomes the publisher of the list
+// and the current time becomes its issue time
+func (l *List) Sign(key crypto.PrivKey) error {
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return xerrors.Errorf("failed to get peer ID of the key: %w", err)
+	}
+	l.Publisher = id
+	l.IssuedAt = time.Now().UTC()
+	data, err := l.signingBytes()
+	if err != nil {
+		return xerrors.Errorf("failed to encode banlist: %w", err)
+	}
+	l.Signature, err = key.Sign(data)
+	if err != nil {
+		return xerrors.Errorf("failed to sign banlist: %w", err)
+	}
+	return nil
+}
+
+// Verify checks that the list is signed by one of trusted publishers
+func (l *List) Verify(trusted []peer.ID) error {
+	if len(l.Signature) == 0 {
+		return xerrors.Errorf("banlist isn't signed")
+	}
+	if l.IssuedAt.IsZero() {
+		return xerrors.Errorf("banlist doesn't have issue time")
+	}
+	isTrusted := false
+	for _, id := range trusted {
+		if id == l.Publisher {
+			isTrusted = true
+			break
+		}
+	}
+	if !isTrusted {
+		return xerrors.Errorf("banlist publisher %s isn't trusted", l.Publisher)
+	}
+	pubKey, err := l.Publisher.ExtractPublicKey()
+	if err != nil {
+		return xerrors.Errorf("failed to extract public key of publisher %s: %w", l.Publisher, err)
+	}
+	data, err := l.signingBytes()
+	if err != nil {
+		return xerrors.Errorf("failed to encode banlist: %w", err)
+	}
+	ok, err := pubKey.Verify(data, l.Signature)
+	if err != nil || !ok {
+		return xerrors.Errorf("banlist signature of publisher %s is invalid", l.Publisher)
+	}
+	return nil
+}
+
+// Decode parses the list, the list must contain only valid peer IDs
+func Decode(data []byte) (*List, error) {
+	var l List
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, xerrors.Errorf("failed to decode banlist: %w", err)
+	}
+	for i, e := range l.Entries {
+		if e == nil || e.ID.Validate() != nil {
+			return nil, xerrors.Errorf("entry %d of banlist doesn't have valid peer ID", i)
+		}
+	}
+	return &l, nil
+}
+
+// Load reads the list from file or downloads it from https URL
+func Load(source string) (*List, error) {
+	if !isURL(source) {
+		data, err := ioutil.ReadFile(source)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read banlist: %w", err)
+		}
+		return Decode(data)
+	}
+	if !strings.HasPrefix(source, "https://") {
+		return nil, xerrors.Errorf("banlist must be downloaded over https")
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(source)
+	client := &fasthttp.Client{MaxResponseBodySize: maxListSize}
+	if err := client.DoTimeout(req, resp, requestTimeout); err != nil {
+		return nil, xerrors.Errorf("failed to download banlist: %w", err)
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, xerrors.Errorf("failed to download banlist: unexpected response status %d", resp.StatusCode())
+	}
+	return Decode(resp.Body())
+}
+
+// ImportFrom loads the list from file or https URL and imports it. Lists downloaded from URLs must be
+// signed by one of trusted publishers, lists from files must be signed only if they name the publisher.
+func (b *Banlist) ImportFrom(source string) ([]peer.ID, error) {
+	list, err := Load(source)
+	if err != nil {
+		return nil, err
+	}
+	if isURL(source) || len(list.Signature) != 0 || list.Publisher != "" {
+		if err := list.Verify(b.trusted); err != nil {
+			return nil, err
+		}
+	}
+	return b.Import(list, source)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Feeds keeps the banlist subscribed to operator-curated lists, every feed replaces
+// its previously imported entries on refresh.
+type Feeds struct {
+	banlist  *Banlist
+	urls     []string
+	interval time.Duration
+	onBan    func(id peer.ID)
+}
+
+// NewFeeds creates the subscription to feeds, onBan is called for every newly banned peer
+func NewFeeds(b *Banlist, urls []string, interval time.Duration, onBan func(id peer.ID)) *Feeds {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &Feeds{
+		banlist:  b,
+		urls:     urls,
+		interval: interval,
+		onBan:    onBan,
+	}
+}
+
+// Run refreshes feeds periodically, it blocks until ctx is done
+func (f *Feeds) Run(ctx context.Context) {
+	if len(f.urls) == 0 {
+		return
+	}
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		f.refresh()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Feeds) refresh() {
+	for _, url := range f.urls {
+		added, err := f.banlist.ImportFrom(url)
+		if err != nil {
+			// entries of the feed are kept until it's available again
+			logrus.Warnf("Failed to refresh banlist feed %s: %v", url, err)
+			continue
+		}
+		logrus.Debugf("Banlist feed %s has been refreshed, %d peers are banned by it", url, len(added))
+		for _, id := range added {
+			f.onBan(id)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package clockdrift estimates the drift of the local clock relative to peers by exchanging
+// timestamps over direct messages, similarly to NTP. Deadlines and fetch times of tasks are
+// checked against local clocks, so a node with drifting clock proposes tasks other validators reject.
+// Only reference peers, i.e. bootstrap nodes and known validators, are sampled, so peers which
+// anyone can spin up can't shift the estimate.
+package clockdrift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/alerting"
+	"github.com/Secured-Finance/dione/config"
+	"github.com/Secured-Finance/dione/directmsg"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AlertClockDrift = "clock_drift"
+
+	PingTopic = "clock_ping"
+	PongTopic = "clock_pong"
+
+	DefaultTolerance     = 10 * time.Second
+	DefaultCheckInterval = time.Minute
+
+	// MinSamples is the count of reference peers required to estimate the drift, so a single peer can't shift
+	// the estimate. Nodes with fewer reference peers require samples of all of them.
+	MinSamples = 3
+	// samples older than sampleTTL don't describe current state of clocks anymore
+	sampleTTL = 10 * time.Minute
+	// replies with longer round trip are too imprecise to be used
+	maxRoundTrip = 5 * time.Second
+)
+
+type ping struct {
+	SentAt int64 // unix nanos by clock of the sender
+}
+
+type pong struct {
+	PingSentAt int64 // echoed from the ping
+	RepliedAt  int64 // unix nanos by clock of the responder
+}
+
+type sample struct {
+	offset     time.Duration // clock of the peer minus local clock
+	receivedAt time.Time
+}
+
+// Monitor periodically pings connected reference peers and estimates local clock drift as the median
+// of peer clock offsets with the opposite sign. The drift over tolerance is reported to operators
+// and, if configured, makes the node refuse to propose tasks.
+type Monitor struct {
+	host            host.Host
+	messenger       *directmsg.Messenger
+	tolerance       time.Duration
+	checkInterval   time.Duration
+	refuseProposals bool
+	alerter         *alerting.Alerter
+	references      map[peer.ID]struct{}
+	now             func() time.Time
+
+	mutex    sync.Mutex
+	pending  map[peer.ID]int64 // send time of the last unanswered ping to the peer
+	samples  map[peer.ID]sample
+	drift    time.Duration
+	known    bool
+	exceeded bool
+}
+
+// NewMonitor creates the monitor comparing local clock with specified reference peers
+func NewMonitor(h host.Host, messenger *directmsg.Messenger, cfg *config.ClockDriftConfig, references []peer.ID, alerter *alerting.Alerter) *Monitor {
+	m := &Monitor{
+		host:            h,
+		messenger:       messenger,
+		tolerance:       time.Duration(cfg.Tolerance) * time.Second,
+		checkInterval:   time.Duration(cfg.CheckInterval) * time.Second,
+		refuseProposals: cfg.RefuseProposals,
+		alerter:         alerter,
+		now:             time.Now,
+		pending:         map[peer.ID]int64{},
+		samples:         map[peer.ID]sample{},
+		references:      map[peer.ID]struct{}{},
+	}
+	for _, p := range references {
+		m.references[p] = struct{}{}
+	}
+	if m.tolerance <= 0 {
+		m.tolerance = DefaultTolerance
+	}
+	if m.checkInterval <= 0 {
+		m.checkInterval = DefaultCheckInterval
+	}
+	messenger.Hook(PingTopic, m.handlePing)
+	messenger.Hook(PongTopic, m.handlePong)
+	return m
+}
+
+// Drift returns the estimated drift of local clock, positive value means the local clock is ahead of peers.
+// The second value is false if there aren't enough samples to estimate it.
+func (m *Monitor) Drift() (time.Duration, bool) {
+	if m == nil {
+		return 0, false
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.drift, m.known
+}
+
+// Exceeded reports whether the estimated drift is over the tolerance
+func (m *Monitor) Exceeded() bool {
+	if m == nil {
+		return false
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.exceeded
+}
+
+// RefusesProposals reports whether the node mustn't propose tasks because of its clock drift
+func (m *Monitor) RefusesProposals() bool {
+	return m != nil && m.refuseProposals && m.Exceeded()
+}
+
+// Run pings connected peers and updates the drift estimate periodically, it blocks until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		m.pingPeers(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// replies to the previous pings have arrived by now
+			m.evaluate()
+		}
+	}
+}
+
+func (m *Monitor) pingPeers(ctx context.Context) {
+	for _, p := range m.host.Network().Peers() {
+		if _, ok := m.references[p]; !ok {
+			continue
+		}
+		go func(p peer.ID) {
+			sentAt := m.now().UnixNano()
+			data, err := cbor.Marshal(&ping{SentAt: sentAt})
+			if err != nil {
+				logrus.Errorf("Failed to encode clock ping: %v", err)
+				return
+			}
+			m.mutex.Lock()
+			m.pending[p] = sentAt
+			m.mutex.Unlock()
+
+			if err := m.messenger.Send(ctx, p, PingTopic, data); err != nil {
+				logrus.Debugf("Failed to send clock ping to %s: %v", p, err)
+			}
+		}(p)
+	}
+}
+
+func (m *Monitor) handlePing(msg *directmsg.Message) {
+	var req ping
+	if err := cbor.Unmarshal(msg.Data, &req); err != nil {
+		logrus.Warnf("Failed to decode clock ping from %s: %v", msg.From, err)
+		return
+	}
+	data, err := cbor.Marshal(&pong{PingSentAt: req.SentAt, RepliedAt: m.now().UnixNano()})
+	if err != nil {
+		logrus.Errorf("Failed to encode clock pong: %v", err)
+		return
+	}
+	if err := m.messenger.Send(context.Background(), msg.From, PongTopic, data); err != nil {
+		logrus.Debugf("Failed to reply to clock ping of %s: %v", msg.From, err)
+	}
+}
+
+func (m *Monitor) handlePong(msg *directmsg.Message) {
+	receivedAt := m.now()
+	var resp pong
+	if err := cbor.Unmarshal(msg.Data, &resp); err != nil {
+		logrus.Warnf("Failed to decode clock pong from %s: %v", msg.From, err)
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	// only the reply to our last ping is accepted, so peers can't replay or forge samples
+	if sentAt, ok := m.pending[msg.From]; !ok || sentAt != resp.PingSentAt {
+		return
+	}
+	delete(m.pending, msg.From)
+
+	sentAt := time.Unix(0, resp.PingSentAt)
+	rtt := receivedAt.Sub(sentAt)
+	if rtt < 0 || rtt > maxRoundTrip {
+		return
+	}
+	offset := time.Unix(0, resp.RepliedAt).Sub(sentAt.Add(rtt / 2))
+	m.samples[msg.From] = sample{offset: offset, receivedAt: receivedAt}
+}
+
+// evaluate updates the drift estimate from fresh samples and reports it if it's over the tolerance
+func (m *Monitor) evaluate() {
+	m.mutex.Lock()
+	var offsets []time.Duration
+	for p, s := range m.samples {
+		if m.now().Sub(s.receivedAt) > sampleTTL {
+			delete(m.samples, p)
+			continue
+		}
+		offsets = append(offsets, s.offset)
+	}
+	minSamples := MinSamples
+	if len(m.references) < minSamples {
+		minSamples = len(m.references)
+	}
+	m.known = len(offsets) != 0 && len(offsets) >= minSamples
+	if m.known {
+		sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+		m.drift = -offsets[len(offsets)/2]
+	} else {
+		m.drift = 0
+	}
+	m.exceeded = m.known && (m.drift > m.tolerance || m.drift < -m.tolerance)
+	drift, exceeded := m.drift, m.exceeded
+	m.mutex.Unlock()
+
+	if !exceeded {
+		m.alerter.ReportSuccess(AlertClockDrift, "local")
+		return
+	}
+	msg := fmt.Sprintf("local clock drift %s relative to %d reference peers exceeds tolerance %s", drift.Round(time.Millisecond), len(offsets), m.tolerance)
+	logrus.Warnf("Clock drift detected: %s, check time synchronization of the host", msg)
+	m.alerter.ReportFailure(AlertClockDrift, "local", msg)
+}
@@ -0,0 +1,82 @@
+package clockdrift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/Secured-Finance/dione/directmsg"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorEstimatesDrift(t *testing.T) {
+	// the last peer isn't the reference one, so it isn't sampled
+	mn, err := mocknet.FullMeshConnected(context.Background(), MinSamples+2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	hosts := mn.Hosts()
+	cfg := &config.ClockDriftConfig{Tolerance: 10, RefuseProposals: true}
+	var references []peer.ID
+	for _, h := range hosts[1 : MinSamples+1] {
+		references = append(references, h.ID())
+	}
+
+	local := NewMonitor(hosts[0], directmsg.NewMessenger(hosts[0]), cfg, references, nil)
+	local.now = func() time.Time { return time.Now().Add(time.Minute) }
+	for _, h := range hosts[1:] {
+		NewMonitor(h, directmsg.NewMessenger(h), cfg, nil, nil)
+	}
+
+	local.evaluate()
+	_, known := local.Drift()
+	assert.False(t, known)
+	assert.False(t, local.RefusesProposals())
+
+	local.pingPeers(context.Background())
+	assert.Eventually(t, func() bool {
+		local.mutex.Lock()
+		defer local.mutex.Unlock()
+		return len(local.samples) == MinSamples
+	}, 5*time.Second, 10*time.Millisecond)
+
+	local.evaluate()
+	drift, known := local.Drift()
+	assert.True(t, known)
+	assert.InDelta(t, float64(time.Minute), float64(drift), float64(time.Second))
+	assert.True(t, local.Exceeded())
+	assert.True(t, local.RefusesProposals())
+}
+
+func TestMonitorFewReferencePeers(t *testing.T) {
+	mn, err := mocknet.FullMeshConnected(context.Background(), 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	hosts := mn.Hosts()
+	local := NewMonitor(hosts[0], directmsg.NewMessenger(hosts[0]), &config.ClockDriftConfig{}, []peer.ID{hosts[1].ID()}, nil)
+	now := time.Now()
+	local.now = func() time.Time { return now }
+
+	// samples of all reference peers are enough if there are fewer than MinSamples of them
+	local.samples[hosts[1].ID()] = sample{offset: -time.Minute, receivedAt: now}
+	local.evaluate()
+	drift, known := local.Drift()
+	assert.True(t, known)
+	assert.Equal(t, time.Minute, drift)
+}
+
+func TestMonitorIgnoresUnsolicitedPong(t *testing.T) {
+	mn, err := mocknet.FullMeshConnected(context.Background(), 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	hosts := mn.Hosts()
+	local := NewMonitor(hosts[0], directmsg.NewMessenger(hosts[0]), &config.ClockDriftConfig{}, []peer.ID{hosts[1].ID()}, nil)
+
+	local.handlePong(&directmsg.Message{Topic: PongTopic, Data: []byte{0xa0}, From: hosts[1].ID()})
+	assert.Empty(t, local.samples)
+}
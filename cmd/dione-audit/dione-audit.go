@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Secured-Finance/dione/audit"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <path to signing audit log>\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open audit log: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	last, err := audit.Verify(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit log verification failed: %v\n", err)
+		os.Exit(1)
+	}
+	if last == nil {
+		fmt.Println("audit log is empty")
+		return
+	}
+	fmt.Printf("audit log is valid: %d records, last record at %s, head hash %s\n", last.Seq, last.Time, last.Hash)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/Secured-Finance/dione/banlist"
+	"github.com/Secured-Finance/dione/datadir"
+	"github.com/Secured-Finance/dione/keystore"
+	"golang.org/x/xerrors"
+)
+
+const banlistUsage = `Usage: dione banlist sign [-datadir <path>] [-out <path>] <path>
+
+Signs the banlist exported by GET /admin/banlist with the identity key of the data directory,
+so it can be published as a feed or imported by nodes trusting its peer ID in banlist.trusted_publishers.
+The signed list is printed to stdout unless -out is set.`
+
+func runBanlistCommand(args []string) error {
+	if len(args) == 0 || args[0] != "sign" {
+		return xerrors.New(banlistUsage)
+	}
+
+	fs := flag.NewFlagSet("banlist sign", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, banlistUsage) }
+	dataDirPath := fs.String("datadir", datadir.DefaultPath(), "Path to data directory")
+	out := fs.String("out", "", "Path to write the signed banlist")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return xerrors.New(banlistUsage)
+	}
+
+	list, err := banlist.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	dataDir, err := datadir.Open(*dataDirPath)
+	if err != nil {
+		return err
+	}
+	defer dataDir.Close()
+	key, err := keystore.LoadIdentityKey(dataDir)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return xerrors.Errorf("data directory %s doesn't have identity key", dataDir.Root())
+	}
+	if err := list.Sign(key); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return ioutil.WriteFile(*out, data, 0644)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Secured-Finance/dione/config"
+	"golang.org/x/xerrors"
+)
+
+const configUsage = `Usage: dione config print-effective [-config <path>]
+
+Prints the config the node would run with: defaults overridden by the config file,
+overridden by DIONE_* environment variables. Secret values are redacted.
+Problems found by config validation are reported after the config.`
+
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print-effective" {
+		return xerrors.New(configUsage)
+	}
+
+	fs := flag.NewFlagSet("print-effective", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, configUsage) }
+	configPath := fs.String("config", "", "Path to config")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return xerrors.Errorf("failed to load config: %w", err)
+	}
+	settings := cfg.Settings()
+	for _, key := range config.Keys() {
+		value, err := json.Marshal(settings[key])
+		if err != nil {
+			return xerrors.Errorf("failed to encode value of %s: %w", key, err)
+		}
+		fmt.Printf("%s = %s (%s)\n", key, value, config.EnvVarName(key))
+	}
+	return cfg.Validate()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/Secured-Finance/dione/diagnostics"
+	"golang.org/x/xerrors"
+)
+
+const diagnosticsTokenEnv = "DIONE_DIAGNOSTICS_TOKEN"
+
+const debugUsage = `Usage: dione debug profile [-addr <host:port>] [-type cpu|heap|goroutine|trace] [-duration 30s] [-out <path>]
+
+Collects the profile from the diagnostics server of a running node. The server is enabled by
+diagnostics.enabled option, its token is read from ` + diagnosticsTokenEnv + ` environment variable.
+The profile is written to the current directory unless -out is set. CPU and heap profiles are viewed
+by go tool pprof, traces by go tool trace, goroutine dump is plain text.`
+
+func runDebugCommand(args []string) error {
+	if len(args) == 0 || args[0] != "profile" {
+		return xerrors.New(debugUsage)
+	}
+
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, debugUsage) }
+	addr := fs.String("addr", config.DefaultDiagnosticsListenAddr, "Address of the diagnostics server")
+	kind := fs.String("type", diagnostics.ProfileCPU, "Profile type")
+	duration := fs.Duration("duration", 30*time.Second, "Duration of cpu profile or trace")
+	out := fs.String("out", "", "Path to write the profile")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *kind == diagnostics.ProfileCPU || *kind == diagnostics.ProfileTrace {
+		fmt.Fprintf(os.Stderr, "Collecting %s profile for %s...\n", *kind, *duration)
+	}
+	data, err := diagnostics.FetchProfile(*addr, os.Getenv(diagnosticsTokenEnv), *kind, *duration)
+	if err != nil {
+		return err
+	}
+
+	path := *out
+	if path == "" {
+		ext := "pprof"
+		switch *kind {
+		case diagnostics.ProfileGoroutine:
+			ext = "txt"
+		case diagnostics.ProfileTrace:
+			ext = "trace"
+		}
+		path = fmt.Sprintf("%s-%s.%s", *kind, time.Now().Format("20060102-150405"), ext)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return xerrors.Errorf("failed to write profile: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Profile is written to %s\n", path)
+	return nil
+}
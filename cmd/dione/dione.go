@@ -1,9 +1,29 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/Secured-Finance/dione/node"
 )
 
+var commands = map[string]func(args []string) error{
+	"banlist": runBanlistCommand,
+	"config":  runConfigCommand,
+	"debug":   runDebugCommand,
+	"init":    runInitCommand,
+	"keys":    runKeysCommand,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
 	node.Start()
 }
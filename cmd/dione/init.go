@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/Secured-Finance/dione/datadir"
+	"github.com/Secured-Finance/dione/node"
+	"golang.org/x/xerrors"
+)
+
+func runInitCommand(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: dione init [-datadir <path>] [-port <port>] [-info <path>] [-force]")
+		fmt.Fprintln(os.Stderr, "\nGenerates node identity and ethereum keys, default config and data directory layout.")
+		fs.PrintDefaults()
+	}
+	dataDirPath := fs.String("datadir", datadir.DefaultPath(), "Path to data directory")
+	listenPort := fs.Int("port", 8000, "Listen port written to the config")
+	infoPath := fs.String("info", "", "Path to write public node info JSON for network operators")
+	force := fs.Bool("force", false, "Regenerate existing keys and config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	info, err := node.InitNode(*dataDirPath, *listenPort, *force)
+	if err != nil {
+		return xerrors.Errorf("failed to initialize node: %w", err)
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *infoPath != "" {
+		if err := ioutil.WriteFile(*infoPath, data, 0644); err != nil {
+			return xerrors.Errorf("failed to write node info: %w", err)
+		}
+	}
+	fmt.Println(string(data))
+	fmt.Fprintf(os.Stderr, "Node is initialized in %s, fill in ethereum and filecoin settings of config.toml before start\n", *dataDirPath)
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Secured-Finance/dione/datadir"
+	"github.com/Secured-Finance/dione/keystore"
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/xerrors"
+)
+
+const passphraseEnv = "DIONE_KEYS_PASSPHRASE"
+
+const keysUsage = `Usage: dione keys <command> [-datadir <path>] [options]
+
+Commands:
+  list                                   show keys with their peer ID or ethereum address
+  export [-out <path>]                   export keys encrypted with a passphrase
+  import [-force] <path>                 import exported keys, existing keys are kept unless -force is set
+
+Passphrase is read from -passphrase-file, ` + passphraseEnv + ` environment variable or terminal.`
+
+func runKeysCommand(args []string) error {
+	if len(args) == 0 {
+		return xerrors.New(keysUsage)
+	}
+
+	fs := flag.NewFlagSet("keys "+args[0], flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, keysUsage) }
+	dataDirPath := fs.String("datadir", datadir.DefaultPath(), "Path to data directory")
+	passphraseFile := fs.String("passphrase-file", "", "Path to file containing passphrase")
+	out := fs.String("out", "", "Path to write exported keys, stdout by default")
+	force := fs.Bool("force", false, "Overwrite existing keys on import")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	dataDir, err := datadir.Open(*dataDirPath)
+	if err != nil {
+		return err
+	}
+	defer dataDir.Close()
+
+	switch args[0] {
+	case "list":
+		keys, err := keystore.List(dataDir)
+		if err != nil {
+			return err
+		}
+		return printJSON(keys)
+	case "export":
+		passphrase, err := readPassphrase(*passphraseFile, true)
+		if err != nil {
+			return err
+		}
+		data, err := keystore.Export(dataDir, passphrase)
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			fmt.Println(string(data))
+			return nil
+		}
+		return ioutil.WriteFile(*out, data, 0600)
+	case "import":
+		if fs.NArg() != 1 {
+			return xerrors.New(keysUsage)
+		}
+		data, err := ioutil.ReadFile(fs.Arg(0))
+		if err != nil {
+			return xerrors.Errorf("failed to read exported keys: %w", err)
+		}
+		passphrase, err := readPassphrase(*passphraseFile, false)
+		if err != nil {
+			return err
+		}
+		keys, err := keystore.Import(dataDir, data, passphrase, *force)
+		if err != nil {
+			return err
+		}
+		return printJSON(keys)
+	default:
+		return xerrors.New(keysUsage)
+	}
+}
+
+func readPassphrase(path string, confirm bool) (string, error) {
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", xerrors.Errorf("failed to read passphrase file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", xerrors.Errorf("failed to read passphrase: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	p, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", xerrors.Errorf("failed to read passphrase: %w", err)
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		p2, err := terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", xerrors.Errorf("failed to read passphrase: %w", err)
+		}
+		if string(p) != string(p2) {
+			return "", xerrors.Errorf("passphrases don't match")
+		}
+	}
+	return string(p), nil
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -3,39 +3,162 @@ package config
 import (
 	"fmt"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
+const (
+	NodeModeValidator = "validator"
+	NodeModeFollower  = "follower" // syncs and serves queries, but never takes part in consensus
+	NodeModeSeed      = "seed"     // only helps other nodes to discover each other
+)
+
 type Config struct {
-	ListenPort            int            `mapstructure:"listen_port"`
-	ListenAddr            string         `mapstructure:"listen_addr"`
-	IsBootstrap           bool           `mapstructure:"is_bootstrap"`
-	BootstrapNodes        []string       `mapstructure:"bootstrap_node_multiaddr"`
-	Rendezvous            string         `mapstructure:"rendezvous"`
-	Ethereum              EthereumConfig `mapstructure:"ethereum"`
-	Filecoin              FilecoinConfig `mapstructure:"filecoin"`
-	PubSub                PubSubConfig   `mapstructure:"pubSub"`
-	Store                 StoreConfig    `mapstructure:"store"`
-	ConsensusMinApprovals int            `mapstructure:"consensus_min_approvals"`
-	Redis                 RedisConfig    `mapstructure:"redis"`
-	CacheType             string         `mapstructure:"cache_type"`
+	Mode                  string                      `mapstructure:"mode"`
+	Listen                ListenConfig                `mapstructure:"listen"`
+	ListenPort            int                         `mapstructure:"listen_port"` // deprecated, use listen.p2p
+	ListenAddr            string                      `mapstructure:"listen_addr"` // deprecated, use listen.p2p
+	IsBootstrap           bool                        `mapstructure:"is_bootstrap"`
+	BootstrapNodes        []string                    `mapstructure:"bootstrap_node_multiaddr"`
+	MinPeers              int                         `mapstructure:"min_peers"`
+	Rendezvous            string                      `mapstructure:"rendezvous"`
+	Ethereum              EthereumConfig              `mapstructure:"ethereum"`
+	Filecoin              FilecoinConfig              `mapstructure:"filecoin"`
+	Solana                SolanaConfig                `mapstructure:"solana"`
+	PubSub                PubSubConfig                `mapstructure:"pubSub"`
+	Store                 StoreConfig                 `mapstructure:"store"`
+	ConsensusMinApprovals int                         `mapstructure:"consensus_min_approvals"`
+	TaskDeadline          int                         `mapstructure:"task_deadline"`     // in secs since the answer is fetched, capped by the request deadline
+	MaxAnswerAge          int                         `mapstructure:"max_answer_age"`    // in secs, older answers are fetched again, has no effect over task_deadline
+	ShutdownTimeout       int                         `mapstructure:"shutdown_timeout"`  // in secs the node waits for in-flight consensus rounds on exit
+	MessageRetention      int                         `mapstructure:"message_retention"` // in secs consensus messages are stored for replay
+	Redis                 RedisConfig                 `mapstructure:"redis"`
+	CacheType             string                      `mapstructure:"cache_type"`
+	DataDir               string                      `mapstructure:"data_dir"`
+	OutboundProxy         string                      `mapstructure:"outbound_proxy"`  // proxy of data source requests, e.g. socks5h://127.0.0.1:9050
+	FaultInjection        []string                    `mapstructure:"fault_injection"` // takes effect only in builds with byzantine tag
+	Tracing               TracingConfig               `mapstructure:"tracing"`
+	Alerting              AlertingConfig              `mapstructure:"alerting"`
+	Admin                 AdminConfig                 `mapstructure:"admin"`
+	Diagnostics           DiagnosticsConfig           `mapstructure:"diagnostics"`
+	FetchLimits           map[string]FetchLimitConfig `mapstructure:"fetch_limits"` // keyed by origin chain or "<chain>/<request type>"
+	ClockDrift            ClockDriftConfig            `mapstructure:"clock_drift"`
+	Banlist               BanlistConfig               `mapstructure:"banlist"`
+	MetricsSnapshots      MetricsSnapshotsConfig      `mapstructure:"metrics_snapshots"`
+	Logging               LoggingConfig               `mapstructure:"logging"`
+
+	deprecatedKeys []string // deprecated fields set by the config file or environment
 }
 
 type EthereumConfig struct {
-	GatewayAddress              string `mapstructure:"gateway_address"`
-	ChainID                     int    `mapstructure:"chain_id"`
-	PrivateKey                  string `mapstructure:"private_key"`
-	MnemonicPhrase              string `mapstructure:"mnemonic_phrase"`
-	HDDerivationPath            string `mapstructure:"hd_derivation_path"`
-	DioneOracleContractAddress  string `mapstructure:"oracle_contract_address"`
-	DioneStakingContractAddress string `mapstructure:"staking_contract_address"`
-	DisputeContractAddress      string `mapstructure:"dispute_contract_address"`
-	DisputeVoteWindow           int    `mapstructure:"dispute_vote_window"` // in secs
+	GatewayAddress              string    `mapstructure:"gateway_address"`
+	ChainID                     int       `mapstructure:"chain_id"`
+	PrivateKey                  string    `mapstructure:"private_key"`
+	PrivateKeyFile              string    `mapstructure:"private_key_file"` // hex-encoded key file, used if private_key isn't set
+	MnemonicPhrase              string    `mapstructure:"mnemonic_phrase"`
+	HDDerivationPath            string    `mapstructure:"hd_derivation_path"`
+	DioneOracleContractAddress  string    `mapstructure:"oracle_contract_address"`
+	DioneStakingContractAddress string    `mapstructure:"staking_contract_address"`
+	DisputeContractAddress      string    `mapstructure:"dispute_contract_address"`
+	DisputeVoteWindow           int       `mapstructure:"dispute_vote_window"` // in secs
+	RPCTLS                      TLSConfig `mapstructure:"rpc_tls"`             // applies to data source requests only
+	Confirmations               uint64    `mapstructure:"confirmations"`       // count of confirmations required for blocks the oracle answers about
 }
 
 type FilecoinConfig struct {
-	LotusHost  string `mapstructure:"lotusHost"`
-	LotusToken string `mapstructure:"lotusToken"`
+	LotusHost           string           `mapstructure:"lotusHost"`
+	LotusHosts          []string         `mapstructure:"lotusHosts"` // additional endpoints balanced together with LotusHost
+	LotusToken          string           `mapstructure:"lotusToken"`
+	HealthCheckInterval int              `mapstructure:"health_check_interval"` // in secs
+	Confirmations       int64            `mapstructure:"confirmations"`         // in epochs
+	Proxy               LotusProxyConfig `mapstructure:"proxy"`
+	TLS                 TLSConfig        `mapstructure:"tls"`
+}
+
+type SolanaConfig struct {
+	TLS TLSConfig `mapstructure:"tls"`
+}
+
+// TLSConfig contains TLS options of outbound connections to the data source
+type TLSConfig struct {
+	CertFile   string `mapstructure:"cert_file"` // client certificate for mutual TLS
+	KeyFile    string `mapstructure:"key_file"`
+	CAFile     string `mapstructure:"ca_file"`     // custom CA bundle to verify server certificate
+	ServerName string `mapstructure:"server_name"` // SNI override
+}
+
+type LotusProxyConfig struct {
+	Enabled        bool              `mapstructure:"enabled"`
+	ListenAddr     string            `mapstructure:"listen_addr"` // deprecated, use listen.lotus_proxy
+	AllowedMethods []string          `mapstructure:"allowed_methods"`
+	Tokens         map[string]string `mapstructure:"tokens"`           // caller name -> bearer token
+	CacheTTL       int               `mapstructure:"cache_ttl"`        // in secs
+	QuotaPerMinute int               `mapstructure:"quota_per_minute"` // 0 means unlimited
+}
+
+type TracingConfig struct {
+	Exporter    string `mapstructure:"exporter"` // "jaeger", "otlp" or empty to disable tracing
+	Endpoint    string `mapstructure:"endpoint"`
+	ServiceName string `mapstructure:"service_name"`
+}
+
+type AlertingConfig struct {
+	Webhooks            []string `mapstructure:"webhooks"`
+	PagerDutyRoutingKey string   `mapstructure:"pagerduty_routing_key"`
+	FailureThreshold    int      `mapstructure:"failure_threshold"` // count of consecutive failures before alert fires
+	Cooldown            int      `mapstructure:"cooldown"`          // in secs
+}
+
+// AdminConfig configures remote administration API, it must not share listen address with public APIs
+type AdminConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	ListenAddr string `mapstructure:"listen_addr"` // deprecated, use listen.admin
+	Token      string `mapstructure:"token"`       // bearer token required by every request
+}
+
+// FetchLimitConfig limits requests of the node to the data source, zero values mean no limit
+type FetchLimitConfig struct {
+	MaxConcurrent int     `mapstructure:"max_concurrent"`
+	Rate          float64 `mapstructure:"rate"` // requests per second
+}
+
+// DiagnosticsConfig configures the server exposing pprof profiles and runtime stats
+type DiagnosticsConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	ListenAddr string `mapstructure:"listen_addr"` // deprecated, use listen.diagnostics
+	Token      string `mapstructure:"token"`       // optional bearer token, the server should be bound to loopback without it
+}
+
+// ClockDriftConfig configures estimation of local clock drift relative to peers
+type ClockDriftConfig struct {
+	Tolerance       int      `mapstructure:"tolerance"`        // in secs, drift over it is reported
+	CheckInterval   int      `mapstructure:"check_interval"`   // in secs
+	RefuseProposals bool     `mapstructure:"refuse_proposals"` // don't propose tasks while drift is over the tolerance
+	ReferencePeers  []string `mapstructure:"reference_peers"`  // peer IDs of known validators, bootstrap nodes are always used
+}
+
+// BanlistConfig configures lists of banned peers shared between nodes
+type BanlistConfig struct {
+	Feeds             []string `mapstructure:"feeds"`              // URLs of signed lists the node is subscribed to
+	TrustedPublishers []string `mapstructure:"trusted_publishers"` // peer IDs allowed to sign imported lists
+	RefreshInterval   int      `mapstructure:"refresh_interval"`   // in secs
+}
+
+// MetricsSnapshotsConfig configures periodic dumps of node metrics into the data directory
+type MetricsSnapshotsConfig struct {
+	Interval int `mapstructure:"interval"`  // in secs, snapshots are disabled if it's zero
+	MaxFiles int `mapstructure:"max_files"` // count of files kept in the ring
+}
+
+// LoggingConfig configures outputs of the node log
+type LoggingConfig struct {
+	Console        bool   `mapstructure:"console"`         // write the log to stderr
+	File           string `mapstructure:"file"`            // relative paths are resolved against logs directory of the data dir
+	MaxSize        int    `mapstructure:"max_size"`        // in MiB, the file is rotated once it reaches the size
+	RotateInterval int    `mapstructure:"rotate_interval"` // in secs, the file is rotated after it regardless of its size
+	MaxBackups     int    `mapstructure:"max_backups"`     // count of rotated files kept
+	MaxAge         int    `mapstructure:"max_age"`         // in secs rotated files are kept for
+	Compress       bool   `mapstructure:"compress"`        // gzip rotated files
 }
 
 type PubSubConfig struct {
@@ -53,20 +176,48 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"redis_db"`
 }
 
-// NewConfig creates a new config based on default values or provided .env file
+// IsFollower reports whether the node runs without keys and doesn't participate in consensus
+func (c *Config) IsFollower() bool {
+	return c.Mode == NodeModeFollower
+}
+
+// IsSeed reports whether the node runs only peer discovery and exchange
+func (c *Config) IsSeed() bool {
+	return c.Mode == NodeModeSeed
+}
+
+// NewConfig loads the config and validates it
 func NewConfig(configPath string) (*Config, error) {
+	cfg, err := Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Load creates a new config based on default values, provided config file and DIONE_* environment variables.
+// Environment variables take precedence over the config file, config path may be empty to use only environment.
+func Load(configPath string) (*Config, error) {
 	dbName := "dione"
 	username := "user"
 	password := "password"
 	dbURL := fmt.Sprintf("host=localhost user=%s password=%s dbname=%s sslmode=disable", username, password, dbName)
 
 	cfg := &Config{
+		Mode:           NodeModeValidator,
 		ListenAddr:     "localhost",
 		ListenPort:     8000,
 		BootstrapNodes: []string{"/ip4/127.0.0.1/tcp/0"},
 		Rendezvous:     "filecoin-p2p-oracle",
 		Ethereum: EthereumConfig{
-			PrivateKey: "",
+			PrivateKey:    "",
+			Confirmations: 12,
+		},
+		Filecoin: FilecoinConfig{
+			Confirmations: 900, // finality
 		},
 		PubSub: PubSubConfig{
 			ProtocolID: "p2p-oracle",
@@ -80,18 +231,35 @@ func NewConfig(configPath string) (*Config, error) {
 			DB:       0,
 		},
 		CacheType: "in-memory",
+		Tracing: TracingConfig{
+			ServiceName: "dione",
+		},
+		Logging: LoggingConfig{
+			Console: true,
+		},
 	}
 
-	viper.SetConfigFile(configPath)
-	err := viper.ReadInConfig()
-	if err != nil {
+	v := viper.New()
+	if configPath != "" {
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if err := bindEnv(v); err != nil {
 		return nil, err
 	}
 
-	err = viper.Unmarshal(cfg)
+	// unknown keys are rejected, so typos don't silently leave default values
+	err := v.UnmarshalExact(cfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToMapHookFunc(),
+	)))
 	if err != nil {
-		return nil, err
+		return nil, &ValidationError{Problems: decodeProblems(err)}
 	}
+	cfg.resolveListen(v)
 
 	return cfg, nil
 }
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"golang.org/x/xerrors"
+)
+
+// EnvPrefix is the prefix of environment variables overriding config fields.
+// Variable name is the upper-cased field path joined by underscores,
+// e.g. ethereum.gateway_address is overridden by DIONE_ETHEREUM_GATEWAY_ADDRESS.
+const EnvPrefix = "DIONE"
+
+const redactedValue = "<redacted>"
+
+// secretKeys are config fields which values are never printed
+var secretKeys = map[string]struct{}{
+	"ethereum.private_key":           {},
+	"ethereum.mnemonic_phrase":       {},
+	"filecoin.lotustoken":            {},
+	"filecoin.proxy.tokens":          {},
+	"redis.redis_password":           {},
+	"store.database_url":             {},
+	"alerting.pagerduty_routing_key": {},
+	"admin.token":                    {},
+	"diagnostics.token":              {},
+}
+
+// EnvVarName returns the name of environment variable overriding config field with specified key
+func EnvVarName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys returns keys of all config fields
+func Keys() []string {
+	var keys []string
+	walkFields(reflect.ValueOf(Config{}), "", func(key string, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// bindEnv binds every config field to its environment variable, so env overrides values from config file
+func bindEnv(v *viper.Viper) error {
+	for _, key := range Keys() {
+		if err := v.BindEnv(key, EnvVarName(key)); err != nil {
+			return xerrors.Errorf("failed to bind env variable of %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Settings returns effective values of all config fields keyed by their paths, secret values are redacted
+func (c *Config) Settings() map[string]interface{} {
+	settings := map[string]interface{}{}
+	walkFields(reflect.ValueOf(*c), "", func(key string, value reflect.Value) {
+		if _, ok := secretKeys[key]; ok && !value.IsZero() {
+			settings[key] = redactedValue
+			return
+		}
+		settings[key] = value.Interface()
+	})
+	return settings
+}
+
+func walkFields(v reflect.Value, prefix string, fn func(key string, value reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + strings.ToLower(name)
+		if t.Field(i).Type.Kind() == reflect.Struct {
+			walkFields(v.Field(i), key+".", fn)
+			continue
+		}
+		fn(key, v.Field(i))
+	}
+}
+
+// stringToMapHookFunc decodes "key1=value1,key2=value2" strings coming from env into string maps
+func stringToMapHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Map || to.Key().Kind() != reflect.String || to.Elem().Kind() != reflect.String {
+			return data, nil
+		}
+		res := map[string]string{}
+		s := data.(string)
+		if s == "" {
+			return res, nil
+		}
+		for _, kv := range strings.Split(s, ",") {
+			p := strings.SplitN(kv, "=", 2)
+			if len(p) != 2 {
+				return nil, xerrors.Errorf("invalid map entry %q, expected key=value", kv)
+			}
+			res[strings.TrimSpace(p[0])] = strings.TrimSpace(p[1])
+		}
+		return res, nil
+	}
+}
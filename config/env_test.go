@@ -0,0 +1,44 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("listen_port: 9000\nethereum:\n  gateway_address: ws://file\n"), 0600))
+
+	env := map[string]string{
+		"DIONE_ETHEREUM_GATEWAY_ADDRESS": "ws://env",
+		"DIONE_BOOTSTRAP_NODE_MULTIADDR": "/ip4/1.1.1.1/tcp/1,/ip4/2.2.2.2/tcp/2",
+		"DIONE_FILECOIN_PROXY_TOKENS":    "a=1,b=2",
+		"DIONE_ETHEREUM_PRIVATE_KEY":     "secret",
+		"DIONE_ETHEREUM_CONFIRMATIONS":   "3",
+		"DIONE_FILECOIN_PROXY_CACHE_TTL": "7",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	cfg, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, cfg.ListenPort)
+	assert.Equal(t, "ws://env", cfg.Ethereum.GatewayAddress)
+	assert.Equal(t, []string{"/ip4/1.1.1.1/tcp/1", "/ip4/2.2.2.2/tcp/2"}, cfg.BootstrapNodes)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, cfg.Filecoin.Proxy.Tokens)
+	assert.Equal(t, uint64(3), cfg.Ethereum.Confirmations)
+	assert.Equal(t, 7, cfg.Filecoin.Proxy.CacheTTL)
+	assert.Equal(t, "in-memory", cfg.CacheType)
+
+	settings := cfg.Settings()
+	assert.Equal(t, redactedValue, settings["ethereum.private_key"])
+	assert.Equal(t, "ws://env", settings["ethereum.gateway_address"])
+}
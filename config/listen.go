@@ -0,0 +1,180 @@
+package config
+
+import (
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	DefaultAdminListenAddr       = "127.0.0.1:8090"
+	DefaultDiagnosticsListenAddr = "127.0.0.1:6060"
+)
+
+// ListenConfig declares listen addresses of all servers of the node in one place. Its fields take
+// precedence over listen fields of server sections, which are kept as deprecated aliases.
+type ListenConfig struct {
+	P2P         string `mapstructure:"p2p"`         // host:port of libp2p, replaces listen_addr and listen_port
+	Admin       string `mapstructure:"admin"`       // replaces admin.listen_addr
+	Diagnostics string `mapstructure:"diagnostics"` // replaces diagnostics.listen_addr
+	LotusProxy  string `mapstructure:"lotus_proxy"` // replaces filecoin.proxy.listen_addr
+}
+
+// deprecatedListenKeys maps deprecated listen fields to fields of the listen section replacing them
+var deprecatedListenKeys = map[string]string{
+	"listen_addr":                "listen.p2p",
+	"listen_port":                "listen.p2p",
+	"admin.listen_addr":          "listen.admin",
+	"diagnostics.listen_addr":    "listen.diagnostics",
+	"filecoin.proxy.listen_addr": "listen.lotus_proxy",
+}
+
+// resolveListen records deprecated listen fields set by the config file or environment
+// and overrides them by the listen section, so servers read addresses from their own sections
+func (c *Config) resolveListen(v *viper.Viper) {
+	for key := range deprecatedListenKeys {
+		if v.IsSet(key) {
+			c.deprecatedKeys = append(c.deprecatedKeys, key)
+		}
+	}
+	sort.Strings(c.deprecatedKeys)
+
+	if ip, port, err := splitListenAddr(c.Listen.P2P); err == nil {
+		c.ListenAddr, c.ListenPort = ip.String(), port
+	}
+	if c.Listen.Admin != "" {
+		c.Admin.ListenAddr = c.Listen.Admin
+	}
+	if c.Listen.Diagnostics != "" {
+		c.Diagnostics.ListenAddr = c.Listen.Diagnostics
+	}
+	if c.Listen.LotusProxy != "" {
+		c.Filecoin.Proxy.ListenAddr = c.Listen.LotusProxy
+	}
+}
+
+// DeprecatedKey is a deprecated field set by the config file or environment
+type DeprecatedKey struct {
+	Key        string
+	ReplacedBy string
+}
+
+// DeprecatedKeys returns deprecated fields set by the config file or environment sorted by key
+func (c *Config) DeprecatedKeys() []DeprecatedKey {
+	res := make([]DeprecatedKey, 0, len(c.deprecatedKeys))
+	for _, key := range c.deprecatedKeys {
+		res = append(res, DeprecatedKey{Key: key, ReplacedBy: deprecatedListenKeys[key]})
+	}
+	return res
+}
+
+// listenField returns the field declaring the address of the server
+func listenField(unified, deprecated, field string) string {
+	if unified != "" {
+		return field
+	}
+	return deprecated
+}
+
+// Listener is the server of the node accepting connections on its own TCP address
+type Listener struct {
+	Name  string // server name shown in logs
+	Field string // config field declaring the address
+	Addr  string // host:port
+}
+
+// Listeners returns addresses of all servers enabled by the config
+func (c *Config) Listeners() []Listener {
+	listeners := []Listener{{
+		Name:  "libp2p",
+		Field: listenField(c.Listen.P2P, "listen_addr", "listen.p2p"),
+		Addr:  net.JoinHostPort(c.ListenAddr, strconv.Itoa(c.ListenPort)),
+	}}
+	if c.Admin.Enabled {
+		addr := c.Admin.ListenAddr
+		if addr == "" {
+			addr = DefaultAdminListenAddr
+		}
+		listeners = append(listeners, Listener{Name: "admin api", Field: listenField(c.Listen.Admin, "admin.listen_addr", "listen.admin"), Addr: addr})
+	}
+	if c.Diagnostics.Enabled {
+		addr := c.Diagnostics.ListenAddr
+		if addr == "" {
+			addr = DefaultDiagnosticsListenAddr
+		}
+		listeners = append(listeners, Listener{Name: "diagnostics", Field: listenField(c.Listen.Diagnostics, "diagnostics.listen_addr", "listen.diagnostics"), Addr: addr})
+	}
+	if !c.IsSeed() && c.Filecoin.Proxy.Enabled && c.Filecoin.Proxy.ListenAddr != "" {
+		listeners = append(listeners, Listener{Name: "lotus proxy", Field: listenField(c.Listen.LotusProxy, "filecoin.proxy.listen_addr", "listen.lotus_proxy"), Addr: c.Filecoin.Proxy.ListenAddr})
+	}
+	return listeners
+}
+
+// validateListeners checks that addresses of the servers are well-formed and don't overlap
+func (c *Config) validateListeners(v *validator) {
+	if c.Listen.P2P != "" {
+		if _, _, err := splitListenAddr(c.Listen.P2P); err != nil {
+			v.addf("listen.p2p", "invalid listen address %q: %v", c.Listen.P2P, err)
+		}
+	}
+	unified := map[string]string{
+		"listen.p2p":         c.Listen.P2P,
+		"listen.admin":       c.Listen.Admin,
+		"listen.diagnostics": c.Listen.Diagnostics,
+		"listen.lotus_proxy": c.Listen.LotusProxy,
+	}
+	for _, key := range c.deprecatedKeys {
+		if field := deprecatedListenKeys[key]; unified[field] != "" {
+			v.addf(field, "deprecated %s is set too, only one of them can be used", key)
+		}
+	}
+
+	var valid []Listener
+	for _, l := range c.Listeners() {
+		if _, _, err := splitListenAddr(l.Addr); err != nil {
+			v.addf(l.Field, "invalid listen address %q: %v", l.Addr, err)
+			continue
+		}
+		for _, other := range valid {
+			if listenAddrsConflict(l.Addr, other.Addr) {
+				v.addf(l.Field, "%s address %s conflicts with %s address %s", l.Name, l.Addr, other.Name, other.Addr)
+			}
+		}
+		valid = append(valid, l)
+	}
+}
+
+func splitListenAddr(addr string) (net.IP, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 65535 {
+		return nil, 0, &net.AddrError{Err: "invalid port", Addr: addr}
+	}
+	if host == "" {
+		return net.IPv4zero, port, nil
+	}
+	if host == "localhost" {
+		return net.IPv4(127, 0, 0, 1), port, nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, 0, &net.AddrError{Err: "host isn't an IP address", Addr: addr}
+	}
+	return ip, port, nil
+}
+
+// listenAddrsConflict reports whether both addresses can't be bound at once.
+// Port 0 is assigned by the system and never conflicts, unspecified host overlaps with any other host.
+func listenAddrsConflict(a, b string) bool {
+	ipA, portA, _ := splitListenAddr(a)
+	ipB, portB, _ := splitListenAddr(b)
+	if portA == 0 || portA != portB {
+		return false
+	}
+	return ipA.IsUnspecified() || ipB.IsUnspecified() || ipA.Equal(ipB)
+}
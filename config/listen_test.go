@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenAddrsConflict(t *testing.T) {
+	assert.True(t, listenAddrsConflict("127.0.0.1:8000", "127.0.0.1:8000"))
+	assert.True(t, listenAddrsConflict("0.0.0.0:8000", "127.0.0.1:8000"))
+	assert.True(t, listenAddrsConflict("localhost:8000", ":8000"))
+	assert.False(t, listenAddrsConflict("127.0.0.1:8000", "127.0.0.1:8001"))
+	assert.False(t, listenAddrsConflict("127.0.0.1:8000", "10.0.0.1:8000"))
+	assert.False(t, listenAddrsConflict("0.0.0.0:0", "0.0.0.0:0"))
+}
+
+func TestValidateListeners(t *testing.T) {
+	cfg := &Config{ListenAddr: "0.0.0.0", ListenPort: 8090}
+	cfg.Admin = AdminConfig{Enabled: true, Token: "token"}
+	cfg.Filecoin.Proxy = LotusProxyConfig{Enabled: true, ListenAddr: "127.0.0.1:8090"}
+
+	v := &validator{}
+	cfg.validateListeners(v)
+	// both servers conflict with libp2p and the proxy also conflicts with admin api
+	assert.Len(t, v.problems, 3)
+
+	cfg.ListenPort = 8000
+	cfg.Filecoin.Proxy.ListenAddr = "127.0.0.1:8091"
+	v = &validator{}
+	cfg.validateListeners(v)
+	assert.Empty(t, v.problems)
+
+	cfg.Admin.ListenAddr = "localhost"
+	v = &validator{}
+	cfg.validateListeners(v)
+	assert.Len(t, v.problems, 1)
+}
+
+func TestListenSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "listen_port: 9000\nadmin:\n  listen_addr: 127.0.0.1:9001\nlisten:\n  p2p: :9100\n  diagnostics: 127.0.0.1:9102\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0600))
+	os.Setenv("DIONE_LISTEN_LOTUS_PROXY", "127.0.0.1:9103")
+	defer os.Unsetenv("DIONE_LISTEN_LOTUS_PROXY")
+
+	cfg, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.0.0", cfg.ListenAddr)
+	assert.Equal(t, 9100, cfg.ListenPort)
+	// deprecated field is still used when the listen section doesn't declare the server
+	assert.Equal(t, "127.0.0.1:9001", cfg.Admin.ListenAddr)
+	assert.Equal(t, "127.0.0.1:9102", cfg.Diagnostics.ListenAddr)
+	assert.Equal(t, "127.0.0.1:9103", cfg.Filecoin.Proxy.ListenAddr)
+	assert.Equal(t, []DeprecatedKey{
+		{Key: "admin.listen_addr", ReplacedBy: "listen.admin"},
+		{Key: "listen_port", ReplacedBy: "listen.p2p"},
+	}, cfg.DeprecatedKeys())
+
+	// listen_port and listen.p2p are both set
+	v := &validator{}
+	cfg.validateListeners(v)
+	assert.Len(t, v.problems, 1)
+
+	cfg.deprecatedKeys = nil
+	cfg.Listen.P2P = "localhost"
+	v = &validator{}
+	cfg.validateListeners(v)
+	assert.Len(t, v.problems, 1)
+}
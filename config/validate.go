@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/mitchellh/mapstructure"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// ValidationError contains all problems found in the config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("config is invalid:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf(field, "unknown value %q, expected one of %q", value, allowed)
+}
+
+func (v *validator) url(field, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		v.addf(field, "invalid url %q: %v", value, err)
+		return
+	}
+	if u.Host == "" {
+		v.addf(field, "url %q doesn't contain host", value)
+		return
+	}
+	if len(schemes) != 0 {
+		v.oneOf(field, u.Scheme, schemes...)
+	}
+}
+
+func (v *validator) address(field, value string) {
+	if !common.IsHexAddress(value) {
+		v.addf(field, "invalid ethereum address %q", value)
+	}
+}
+
+func (v *validator) tls(field string, cfg *TLSConfig) {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		v.addf(field, "cert_file and key_file must be specified together")
+	}
+}
+
+// Validate checks the config and returns ValidationError listing all found problems
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("mode", c.Mode, NodeModeValidator, NodeModeFollower, NodeModeSeed)
+	if c.ListenPort < 0 || c.ListenPort > 65535 {
+		v.addf("listen_port", "port %d is out of range", c.ListenPort)
+	}
+	if !c.IsBootstrap && len(c.BootstrapNodes) == 0 {
+		v.addf("bootstrap_node_multiaddr", "at least one bootstrap node is required")
+	}
+	for i, a := range c.BootstrapNodes {
+		if _, err := multiaddr.NewMultiaddr(a); err != nil {
+			v.addf(fmt.Sprintf("bootstrap_node_multiaddr[%d]", i), "invalid multiaddress %q: %v", a, err)
+		}
+	}
+
+	if !c.IsSeed() {
+		c.validateSources(v)
+	}
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "", "jaeger", "otlp")
+	if c.Tracing.Exporter != "" && c.Tracing.Endpoint == "" {
+		v.addf("tracing.endpoint", "endpoint is required by %s exporter", c.Tracing.Exporter)
+	}
+	for i, w := range c.Alerting.Webhooks {
+		v.url(fmt.Sprintf("alerting.webhooks[%d]", i), w, "http", "https")
+	}
+
+	if !c.Logging.Console && c.Logging.File == "" {
+		v.addf("logging.file", "log file is required if console output is disabled")
+	}
+	if c.Logging.MaxSize < 0 || c.Logging.RotateInterval < 0 || c.Logging.MaxBackups < 0 || c.Logging.MaxAge < 0 {
+		v.addf("logging", "rotation limits must not be negative")
+	}
+
+	if c.Admin.Enabled {
+		if c.Admin.Token == "" {
+			v.addf("admin.token", "token is required by enabled admin api")
+		}
+	}
+	if c.Diagnostics.Enabled && c.Diagnostics.Token == "" {
+		addr := c.Diagnostics.ListenAddr
+		if addr == "" {
+			addr = DefaultDiagnosticsListenAddr
+		}
+		// profiles and heap dumps expose node internals, so only loopback is served without a token
+		if ip, _, err := splitListenAddr(addr); err == nil && !ip.IsLoopback() {
+			v.addf("diagnostics.token", "token is required if diagnostics server isn't bound to loopback address")
+		}
+	}
+	c.validateListeners(v)
+
+	for i, f := range c.Banlist.Feeds {
+		v.url(fmt.Sprintf("banlist.feeds[%d]", i), f, "https")
+	}
+	for i, p := range c.Banlist.TrustedPublishers {
+		if _, err := peer.Decode(p); err != nil {
+			v.addf(fmt.Sprintf("banlist.trusted_publishers[%d]", i), "invalid peer ID %q: %v", p, err)
+		}
+	}
+	if len(c.Banlist.Feeds) != 0 && len(c.Banlist.TrustedPublishers) == 0 {
+		v.addf("banlist.trusted_publishers", "at least one trusted publisher is required by banlist feeds")
+	}
+
+	if len(v.problems) != 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validateSources checks config of Ethereum and data sources used by validators and followers
+func (c *Config) validateSources(v *validator) {
+	if c.Ethereum.GatewayAddress == "" {
+		v.addf("ethereum.gateway_address", "gateway address is required")
+	} else {
+		v.url("ethereum.gateway_address", c.Ethereum.GatewayAddress, "http", "https", "ws", "wss")
+	}
+	v.address("ethereum.oracle_contract_address", c.Ethereum.DioneOracleContractAddress)
+	v.address("ethereum.staking_contract_address", c.Ethereum.DioneStakingContractAddress)
+	v.address("ethereum.dispute_contract_address", c.Ethereum.DisputeContractAddress)
+	if !c.IsFollower() && c.Ethereum.PrivateKey == "" && c.Ethereum.PrivateKeyFile == "" && c.Ethereum.MnemonicPhrase == "" {
+		v.addf("ethereum.private_key", "private key, private key file or mnemonic phrase is required by validator")
+	}
+	v.tls("ethereum.rpc_tls", &c.Ethereum.RPCTLS)
+
+	if c.Filecoin.LotusHost != "" {
+		v.url("filecoin.lotusHost", c.Filecoin.LotusHost, "http", "https")
+	}
+	for i, h := range c.Filecoin.LotusHosts {
+		v.url(fmt.Sprintf("filecoin.lotusHosts[%d]", i), h, "http", "https")
+	}
+	v.tls("filecoin.tls", &c.Filecoin.TLS)
+	if c.Filecoin.Proxy.Enabled {
+		if c.Filecoin.Proxy.ListenAddr == "" {
+			v.addf("filecoin.proxy.listen_addr", "listen address is required by enabled proxy")
+		}
+		if len(c.Filecoin.Proxy.AllowedMethods) == 0 {
+			v.addf("filecoin.proxy.allowed_methods", "at least one method is required by enabled proxy")
+		}
+		if len(c.Filecoin.Proxy.Tokens) == 0 {
+			v.addf("filecoin.proxy.tokens", "at least one caller token is required by enabled proxy")
+		}
+	}
+	v.tls("solana.tls", &c.Solana.TLS)
+	for key, l := range c.FetchLimits {
+		if l.MaxConcurrent < 0 || l.Rate < 0 {
+			v.addf("fetch_limits."+key, "limits must not be negative")
+		}
+	}
+	if c.ClockDrift.Tolerance < 0 {
+		v.addf("clock_drift.tolerance", "tolerance must not be negative")
+	}
+	for i, p := range c.ClockDrift.ReferencePeers {
+		if _, err := peer.Decode(p); err != nil {
+			v.addf(fmt.Sprintf("clock_drift.reference_peers[%d]", i), "invalid peer ID %q: %v", p, err)
+		}
+	}
+	if c.MetricsSnapshots.Interval < 0 {
+		v.addf("metrics_snapshots.interval", "interval must not be negative")
+	}
+	if c.MetricsSnapshots.MaxFiles < 0 {
+		v.addf("metrics_snapshots.max_files", "count of files must not be negative")
+	}
+
+	if c.OutboundProxy != "" {
+		v.url("outbound_proxy", c.OutboundProxy, "http", "socks5", "socks5h")
+	}
+	v.oneOf("cache_type", c.CacheType, "in-memory", "redis")
+	if c.CacheType == "redis" && c.Redis.Addr == "" {
+		v.addf("redis.redis_addr", "address is required by redis cache")
+	}
+}
+
+// decodeProblems converts errors of config decoding, e.g. unknown keys or wrong types, into validation problems
+func decodeProblems(err error) []string {
+	if e, ok := err.(*mapstructure.Error); ok {
+		problems := append([]string(nil), e.Errors...)
+		sort.Strings(problems)
+		return problems
+	}
+	return []string{err.Error()}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+listen_port: 8000
+bootstrap_node_multiaddr: ["/ip4/127.0.0.1/tcp/8001"]
+ethereum:
+  gateway_address: ws://localhost:8545
+  private_key: 4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318
+  oracle_contract_address: "0x0000000000000000000000000000000000000001"
+  staking_contract_address: "0x0000000000000000000000000000000000000002"
+  dispute_contract_address: "0x0000000000000000000000000000000000000003"
+filecoin:
+  lotusHost: http://localhost:1234/rpc/v0
+`), 0600))
+	_, err := NewConfig(path)
+	assert.NoError(t, err)
+
+	cfg, err := Load(path)
+	assert.NoError(t, err)
+	cfg.Mode = "full"
+	cfg.BootstrapNodes = []string{"not a multiaddr"}
+	cfg.Ethereum.GatewayAddress = "localhost"
+	cfg.Ethereum.PrivateKey = ""
+	cfg.Tracing.Exporter = "jaeger"
+	err = cfg.Validate()
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Len(t, err.(*ValidationError).Problems, 5)
+	}
+
+	// seed node doesn't need keys and data sources
+	cfg.Mode = NodeModeSeed
+	cfg.BootstrapNodes = nil
+	cfg.IsBootstrap = true
+	cfg.Tracing.Exporter = ""
+	assert.NoError(t, cfg.Validate())
+
+	// diagnostics server is served without a token only on loopback
+	cfg.Diagnostics = DiagnosticsConfig{Enabled: true}
+	assert.NoError(t, cfg.Validate())
+	cfg.Diagnostics.ListenAddr = "0.0.0.0:6060"
+	assert.Error(t, cfg.Validate())
+	cfg.Diagnostics.Token = "token"
+	assert.NoError(t, cfg.Validate())
+
+	// log outputs are checked on seed nodes too
+	cfg.Logging.Console = false
+	assert.Error(t, cfg.Validate())
+	cfg.Logging.File = "dione.log"
+	assert.NoError(t, cfg.Validate())
+
+	// unknown keys are rejected
+	assert.NoError(t, ioutil.WriteFile(path, []byte("listen_prot: 8000\nethereum:\n  gateway: ws://localhost\n"), 0600))
+	_, err = Load(path)
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Len(t, err.(*ValidationError).Problems, 2)
+	}
+}
@@ -0,0 +1,164 @@
+package connectivity
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/addrbook"
+	"github.com/Secured-Finance/dione/alerting"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultMinPeers      = 3
+	DefaultCheckInterval = 10 * time.Second
+	dialTimeout          = 15 * time.Second
+)
+
+// HealthStatus represents the state of node connectivity
+type HealthStatus uint8
+
+const (
+	StatusHealthy = HealthStatus(iota)
+	StatusDegraded
+	StatusIsolated
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusDegraded:
+		return "degraded"
+	case StatusIsolated:
+		return "isolated"
+	default:
+		return "unknown"
+	}
+}
+
+// Maintainer monitors count of connected peers and dials known or
+// bootstrap peers when it drops below configured minimum.
+type Maintainer struct {
+	host           host.Host
+	addrBook       *addrbook.AddressBook
+	bootstrapPeers []peer.AddrInfo
+	minPeers       int
+	checkInterval  time.Duration
+	alerter        *alerting.Alerter
+
+	statusLock sync.RWMutex
+	status     HealthStatus
+}
+
+func NewMaintainer(h host.Host, ab *addrbook.AddressBook, bootstrapPeers []peer.AddrInfo, minPeers int, checkInterval time.Duration, alerter *alerting.Alerter) *Maintainer {
+	if minPeers <= 0 {
+		minPeers = DefaultMinPeers
+	}
+	if checkInterval <= 0 {
+		checkInterval = DefaultCheckInterval
+	}
+	return &Maintainer{
+		host:           h,
+		addrBook:       ab,
+		bootstrapPeers: bootstrapPeers,
+		minPeers:       minPeers,
+		checkInterval:  checkInterval,
+		alerter:        alerter,
+	}
+}
+
+// Run starts the connectivity check loop, it blocks until ctx is done
+func (m *Maintainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *Maintainer) check(ctx context.Context) {
+	connected := m.connectedPeersCount()
+	if connected < m.minPeers {
+		logrus.Debugf("Connected peers count (%d) is below minimum (%d), dialing known peers...", connected, m.minPeers)
+		m.dialPeers(ctx, m.minPeers-connected)
+		connected = m.connectedPeersCount()
+	}
+	m.setStatus(m.statusForCount(connected))
+}
+
+func (m *Maintainer) dialPeers(ctx context.Context, needed int) {
+	candidates := append(m.addrBook.Peers(), m.bootstrapPeers...)
+	for _, p := range candidates {
+		if needed <= 0 {
+			return
+		}
+		if p.ID == m.host.ID() || m.host.Network().Connectedness(p.ID) == network.Connected {
+			continue
+		}
+		dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+		err := m.host.Connect(dialCtx, p)
+		cancel()
+		if err != nil {
+			logrus.Debugf("Failed to connect to peer %s: %v", p.ID, err)
+			m.addrBook.MarkFailed(p.ID)
+			continue
+		}
+		m.addrBook.AddPeer(p)
+		m.addrBook.MarkConnected(p.ID)
+		logrus.Info("Reconnected to peer: ", p.ID)
+		needed--
+	}
+}
+
+func (m *Maintainer) connectedPeersCount() int {
+	return len(m.host.Network().Peers())
+}
+
+func (m *Maintainer) statusForCount(count int) HealthStatus {
+	switch {
+	case count == 0:
+		return StatusIsolated
+	case count < m.minPeers:
+		return StatusDegraded
+	default:
+		return StatusHealthy
+	}
+}
+
+func (m *Maintainer) setStatus(status HealthStatus) {
+	m.statusLock.Lock()
+	prev := m.status
+	m.status = status
+	m.statusLock.Unlock()
+
+	if prev == status {
+		return
+	}
+	switch status {
+	case StatusHealthy:
+		logrus.Infof("Node connectivity has recovered (%s)", status)
+	case StatusIsolated:
+		logrus.Errorf("Node is isolated from the network: no connected peers")
+		m.alerter.Fire(alerting.AlertNodeIsolated, "network", alerting.SeverityCritical, "node is isolated from the network: no connected peers")
+	default:
+		logrus.Warnf("Node connectivity is %s: connected to fewer than %d peers", status, m.minPeers)
+	}
+}
+
+// Status returns current health status of node connectivity
+func (m *Maintainer) Status() HealthStatus {
+	m.statusLock.RLock()
+	defer m.statusLock.RUnlock()
+	return m.status
+}
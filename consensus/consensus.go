@@ -1,8 +1,19 @@
 package consensus
 
 import (
+	"context"
+	"fmt"
 	"math/big"
 	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/alerting"
+	"github.com/Secured-Finance/dione/audit"
+	"github.com/Secured-Finance/dione/deadletter"
+	"github.com/Secured-Finance/dione/reorg"
+	"github.com/Secured-Finance/dione/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Secured-Finance/dione/cache"
 
@@ -10,11 +21,24 @@ import (
 
 	"github.com/Secured-Finance/dione/ethclient"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
 
 	"github.com/Secured-Finance/dione/pubsub"
 	types2 "github.com/Secured-Finance/dione/types"
 )
 
+const (
+	// MaxSubmissionAttempts is the count of attempts to submit on-chain result before the task goes to dead-letter queue
+	MaxSubmissionAttempts = 3
+	submissionRetryDelay  = 5 * time.Second
+	// MaxRemineAttempts is the count of times the request which answer got stale before submission is mined again
+	MaxRemineAttempts = 3
+	// roundCheckInterval is the period of checking rounds which haven't been committed by the task deadline
+	roundCheckInterval = 30 * time.Second
+	// roundRetention is the time finished rounds are kept for, so submissions of their results can be disputed
+	roundRetention = time.Hour
+)
+
 type PBFTConsensusManager struct {
 	psb            *pubsub.PubSubRouter
 	minApprovals   int
@@ -22,9 +46,20 @@ type PBFTConsensusManager struct {
 	msgLog         *MessageLog
 	validator      *ConsensusValidator
 	consensusMap   map[string]*Consensus
+	mapMutex       sync.Mutex
 	ethereumClient *ethclient.EthereumClient
 	miner          *Miner
 	eventCache     cache.EventCache
+	faults         *faultInjector
+	deadLetters    *deadletter.Queue
+	alerter        *alerting.Alerter
+	auditLog       *audit.Log
+	reorgs         *reorg.Monitor
+	remines        map[string]int // count of times the request was mined again, guarded by mapMutex
+
+	lifecycleMutex sync.Mutex
+	stopped        bool
+	inFlight       sync.WaitGroup
 }
 
 type Consensus struct {
@@ -32,9 +67,13 @@ type Consensus struct {
 	Finished             bool
 	IsCurrentMinerLeader bool
 	Task                 *types2.DioneTask
+	ctx                  context.Context
+	span                 trace.Span
+	expired              bool      // the round hasn't been committed by the task deadline
+	closedAt             time.Time // when the round was finished or expired
 }
 
-func NewPBFTConsensusManager(psb *pubsub.PubSubRouter, minApprovals int, privKey []byte, ethereumClient *ethclient.EthereumClient, miner *Miner, evc cache.EventCache) *PBFTConsensusManager {
+func NewPBFTConsensusManager(psb *pubsub.PubSubRouter, minApprovals int, privKey []byte, ethereumClient *ethclient.EthereumClient, miner *Miner, evc cache.EventCache, faults []string, deadLetters *deadletter.Queue, alerter *alerting.Alerter, auditLog *audit.Log, reorgs *reorg.Monitor) *PBFTConsensusManager {
 	pcm := &PBFTConsensusManager{}
 	pcm.psb = psb
 	pcm.miner = miner
@@ -45,27 +84,50 @@ func NewPBFTConsensusManager(psb *pubsub.PubSubRouter, minApprovals int, privKey
 	pcm.ethereumClient = ethereumClient
 	pcm.eventCache = evc
 	pcm.consensusMap = map[string]*Consensus{}
+	pcm.remines = map[string]int{}
+	pcm.faults = newFaultInjector(faults)
+	pcm.deadLetters = deadLetters
+	pcm.alerter = alerter
+	pcm.auditLog = auditLog
+	pcm.reorgs = reorgs
 	pcm.psb.Hook(types.MessageTypePrePrepare, pcm.handlePrePrepare)
 	pcm.psb.Hook(types.MessageTypePrepare, pcm.handlePrepare)
 	pcm.psb.Hook(types.MessageTypeCommit, pcm.handleCommit)
 	return pcm
 }
 
-func (pcm *PBFTConsensusManager) Propose(task types2.DioneTask) error {
-	pcm.createConsensusInfo(&task, true)
+func (pcm *PBFTConsensusManager) Propose(ctx context.Context, task types2.DioneTask) error {
+	if !pcm.enter() {
+		return xerrors.Errorf("consensus manager is shut down")
+	}
+	defer pcm.inFlight.Done()
+
+	pcm.createConsensusInfo(ctx, &task, true)
 
 	prePrepareMsg, err := CreatePrePrepareWithTaskSignature(&task, pcm.privKey)
 	if err != nil {
 		return err
 	}
-	pcm.psb.BroadcastToServiceTopic(prePrepareMsg)
+	prePrepareMsg.Trace = pcm.traceContext(task.ConsensusID)
+	pcm.audit(audit.KindProposal, &prePrepareMsg.Payload.Task, prePrepareMsg.Payload.Task.Signature)
+	for _, msg := range pcm.faults.prePrepareMessages(prePrepareMsg, pcm.privKey) {
+		pcm.psb.BroadcastToServiceTopic(msg)
+	}
 	return nil
 }
 
 func (pcm *PBFTConsensusManager) handlePrePrepare(message *types.Message) {
+	if !pcm.enter() {
+		return
+	}
+	defer pcm.inFlight.Done()
 	if message.Payload.Task.Miner == pcm.miner.address {
 		return
 	}
+	if pcm.msgLog.IsStale(message.Payload.Task.ConsensusID) {
+		logrus.Debugf("received pre_prepare msg for stale consensus, dropping...")
+		return
+	}
 	if pcm.msgLog.Exists(*message) {
 		logrus.Debugf("received existing pre_prepare msg, dropping...")
 		return
@@ -75,19 +137,38 @@ func (pcm *PBFTConsensusManager) handlePrePrepare(message *types.Message) {
 		return
 	}
 
-	pcm.msgLog.AddMessage(*message)
+	if !pcm.msgLog.AddPrePrepare(*message) {
+		logrus.Warnf("received conflicting pre_prepare msg of consensus %s from miner %s, dropping...",
+			message.Payload.Task.ConsensusID, message.Payload.Task.Miner)
+		return
+	}
+	pcm.psb.Store(message)
 
 	prepareMsg, err := NewMessage(message, types.MessageTypePrepare)
 	if err != nil {
 		logrus.Errorf("failed to create prepare message: %v", err)
 	}
 
-	pcm.createConsensusInfo(&message.Payload.Task, false)
+	// continue the trace of the proposer, so the round is seen as a single trace across nodes
+	pcm.createConsensusInfo(tracing.Extract(context.Background(), message.Trace), &message.Payload.Task, false)
+	prepareMsg.Trace = pcm.traceContext(message.Payload.Task.ConsensusID)
 
+	if pcm.faults.withholdVotes() {
+		return
+	}
+	pcm.audit(audit.KindPrepare, &message.Payload.Task, nil)
 	pcm.psb.BroadcastToServiceTopic(&prepareMsg)
 }
 
 func (pcm *PBFTConsensusManager) handlePrepare(message *types.Message) {
+	if !pcm.enter() {
+		return
+	}
+	defer pcm.inFlight.Done()
+	if pcm.msgLog.IsStale(message.Payload.Task.ConsensusID) {
+		logrus.Debugf("received prepare msg for stale consensus, dropping...")
+		return
+	}
 	if pcm.msgLog.Exists(*message) {
 		logrus.Debugf("received existing prepare msg, dropping...")
 		return
@@ -98,17 +179,34 @@ func (pcm *PBFTConsensusManager) handlePrepare(message *types.Message) {
 	}
 
 	pcm.msgLog.AddMessage(*message)
+	pcm.psb.Store(message)
 
 	if len(pcm.msgLog.GetMessagesByTypeAndConsensusID(types.MessageTypePrepare, message.Payload.Task.ConsensusID)) >= pcm.minApprovals {
+		if info := pcm.GetConsensusInfo(message.Payload.Task.ConsensusID); info != nil {
+			info.span.AddEvent("prepared")
+		}
 		commitMsg, err := NewMessage(message, types.MessageTypeCommit)
 		if err != nil {
-			logrus.Errorf("failed to create commit message: %w", err)
+			logrus.Errorf("failed to create commit message: %v", err)
+		}
+		commitMsg.Trace = pcm.traceContext(message.Payload.Task.ConsensusID)
+		if pcm.faults.withholdVotes() {
+			return
 		}
+		pcm.audit(audit.KindCommit, &message.Payload.Task, nil)
 		pcm.psb.BroadcastToServiceTopic(&commitMsg)
 	}
 }
 
 func (pcm *PBFTConsensusManager) handleCommit(message *types.Message) {
+	if !pcm.enter() {
+		return
+	}
+	defer pcm.inFlight.Done()
+	if pcm.msgLog.IsStale(message.Payload.Task.ConsensusID) {
+		logrus.Debugf("received commit msg for stale consensus, dropping...")
+		return
+	}
 	if pcm.msgLog.Exists(*message) {
 		logrus.Debugf("received existing commit msg, dropping...")
 		return
@@ -119,6 +217,7 @@ func (pcm *PBFTConsensusManager) handleCommit(message *types.Message) {
 	}
 
 	pcm.msgLog.AddMessage(*message)
+	pcm.psb.Store(message)
 
 	consensusMsg := message.Payload
 	if len(pcm.msgLog.GetMessagesByTypeAndConsensusID(types.MessageTypeCommit, message.Payload.Task.ConsensusID)) >= pcm.minApprovals {
@@ -128,38 +227,345 @@ func (pcm *PBFTConsensusManager) handleCommit(message *types.Message) {
 			return
 		}
 		info.mutex.Lock()
-		defer info.mutex.Unlock()
-		if info.Finished {
+		// the span of expired round is already ended
+		if info.Finished || info.expired {
+			info.mutex.Unlock()
 			return
 		}
+		// the round is marked finished before the submission, so the lock isn't held while submission is retried
+		info.Finished = true
+		info.closedAt = time.Now()
+		info.mutex.Unlock()
+
 		if info.IsCurrentMinerLeader {
 			logrus.Infof("Submitting on-chain result for consensus ID: %s", consensusMsg.Task.ConsensusID)
-			reqID, ok := new(big.Int).SetString(consensusMsg.Task.RequestID, 10)
-			if !ok {
-				logrus.Errorf("Failed to parse request ID: %v", consensusMsg.Task.RequestID)
-			}
+			pcm.submitResult(info.ctx, &consensusMsg.Task)
+		}
+
+		info.span.AddEvent("committed")
+		info.span.End()
+		pcm.msgLog.Close(consensusMsg.Task.ConsensusID)
+		pcm.alerter.ReportSuccess(alerting.AlertConsensusFailed, "pbft")
+	}
+}
+
+// Run checks rounds which haven't been committed by the task deadline periodically, it blocks until ctx is done
+func (pcm *PBFTConsensusManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(roundCheckInterval)
+	defer ticker.Stop()
 
-			err := pcm.ethereumClient.SubmitRequestAnswer(reqID, consensusMsg.Task.Payload)
-			if err != nil {
-				logrus.Errorf("Failed to submit on-chain result: %v", err)
-			}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pcm.checkExpiredRounds(time.Now())
 		}
+	}
+}
 
-		info.Finished = true
+// checkExpiredRounds reports failure of every round which hasn't been committed by the task deadline,
+// so repeated round failures fire the consensus_failed alert. Rounds closed long ago are pruned.
+func (pcm *PBFTConsensusManager) checkExpiredRounds(now time.Time) {
+	pcm.mapMutex.Lock()
+	rounds := make([]*Consensus, 0, len(pcm.consensusMap))
+	for _, info := range pcm.consensusMap {
+		rounds = append(rounds, info)
+	}
+	pcm.mapMutex.Unlock()
+
+	for _, info := range rounds {
+		info.mutex.Lock()
+		expired := !info.Finished && !info.expired && info.Task.Deadline != 0 && now.After(time.Unix(info.Task.Deadline, 0))
+		if expired {
+			info.expired = true
+			info.closedAt = now
+		}
+		info.mutex.Unlock()
+		if !expired {
+			continue
+		}
+
+		msg := fmt.Sprintf("consensus round %s of request %s wasn't committed by the task deadline", info.Task.ConsensusID, info.Task.RequestID)
+		tracing.Logger(info.ctx).Warnf("Consensus round has failed: %s", msg)
+		tracing.RecordError(info.span, xerrors.New(msg))
+		info.span.End()
+		pcm.alerter.ReportFailure(alerting.AlertConsensusFailed, "pbft", msg)
+	}
+
+	pcm.pruneRounds(now)
+}
+
+// pruneRounds removes rounds finished or expired more than roundRetention ago,
+// messages of expired rounds are dropped with them
+func (pcm *PBFTConsensusManager) pruneRounds(now time.Time) {
+	pcm.mapMutex.Lock()
+	var expired []string
+	for id, info := range pcm.consensusMap {
+		info.mutex.Lock()
+		prune := (info.Finished || info.expired) && now.Sub(info.closedAt) > roundRetention
+		if prune && info.expired {
+			expired = append(expired, id)
+		}
+		info.mutex.Unlock()
+		if prune {
+			delete(pcm.consensusMap, id)
+		}
+	}
+	pcm.mapMutex.Unlock()
+
+	for _, id := range expired {
+		pcm.msgLog.Close(id)
+	}
+}
+
+// Shutdown stops taking part in consensus: new proposals and incoming messages are dropped,
+// so unfinished rounds are abstained from. It waits until messages being handled are done,
+// including on-chain submission of already committed results, or ctx is done.
+func (pcm *PBFTConsensusManager) Shutdown(ctx context.Context) error {
+	pcm.lifecycleMutex.Lock()
+	pcm.stopped = true
+	pcm.lifecycleMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		pcm.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return xerrors.Errorf("in-flight consensus rounds weren't finished: %w", ctx.Err())
+	}
+}
+
+// enter registers the message handling in progress, it reports false if the manager is shut down
+func (pcm *PBFTConsensusManager) enter() bool {
+	pcm.lifecycleMutex.Lock()
+	defer pcm.lifecycleMutex.Unlock()
+
+	if pcm.stopped {
+		return false
+	}
+	pcm.inFlight.Add(1)
+	return true
+}
+
+// submitResult submits the agreed task result on-chain, the task goes to the dead-letter queue if all attempts fail.
+// The result isn't submitted if the source chain block it's based on was reorganized, such task should be mined again.
+// The stale result is mined again right away while the deadline of the request allows it.
+func (pcm *PBFTConsensusManager) submitResult(ctx context.Context, task *types2.DioneTask) {
+	ctx, span := tracing.StartSpan(ctx, "submit", attribute.String("request_id", task.RequestID))
+	defer span.End()
+	log := tracing.Logger(ctx)
+
+	reqID, ok := new(big.Int).SetString(task.RequestID, 10)
+	if !ok {
+		log.Errorf("Failed to parse request ID: %v", task.RequestID)
+		return
+	}
+
+	// the answer of the task mined again is tracked anew
+	reorged := pcm.reorgs.IsReorged(task.RequestID)
+	pcm.reorgs.Untrack(task.RequestID)
+	if reorged {
+		pcm.deadLetterForMining(ctx, task, "source chain block of the answer was reorganized")
+		return
+	}
+
+	var err error
+	for attempt := 1; attempt <= MaxSubmissionAttempts; attempt++ {
+		if staleErr := pcm.miner.staleness.CheckTask(task, time.Now()); staleErr != nil {
+			pcm.remine(ctx, task, staleErr.Error())
+			return
+		}
+		err = pcm.ethereumClient.SubmitRequestAnswer(reqID, task.Payload)
+		if err == nil {
+			pcm.forgetRemines(task.RequestID)
+			pcm.audit(audit.KindSubmission, task, nil)
+			pcm.alerter.ReportSuccess(alerting.AlertSubmissionFailed, "ethereum")
+			return
+		}
+		log.Warnf("Failed to submit on-chain result (attempt %d of %d): %v", attempt, MaxSubmissionAttempts, err)
+		if attempt < MaxSubmissionAttempts {
+			time.Sleep(submissionRetryDelay)
+		}
+	}
+
+	log.Errorf("Failed to submit on-chain result of request %s, moving it to dead-letter queue: %v", task.RequestID, err)
+	tracing.RecordError(span, err)
+	pcm.alerter.ReportFailure(alerting.AlertSubmissionFailed, "ethereum", fmt.Sprintf("failed to submit on-chain result of request %s: %v", task.RequestID, err))
+	if pcm.deadLetters == nil {
+		return
+	}
+	dlErr := pcm.deadLetters.Push(&deadletter.Entry{
+		RequestID:     task.RequestID,
+		OriginChain:   task.OriginChain,
+		RequestType:   task.RequestType,
+		RequestParams: task.RequestParams,
+		Payload:       task.Payload,
+		Stage:         deadletter.StageSubmission,
+		Error:         err.Error(),
+		Attempts:      MaxSubmissionAttempts,
+		TraceID:       tracing.TraceID(ctx),
+	})
+	if dlErr != nil {
+		log.Errorf("Failed to add request %s to dead-letter queue: %v", task.RequestID, dlErr)
+	}
+}
+
+// remine mines the request which answer got stale before submission again and proposes the new task,
+// while the deadline of the request hasn't passed. The request goes to the dead-letter queue if it can't be mined again.
+func (pcm *PBFTConsensusManager) remine(ctx context.Context, task *types2.DioneTask, reason string) {
+	event, err := pcm.eventCache.GetOracleRequestEvent("request_" + task.RequestID)
+	if err != nil || event == nil {
+		pcm.deadLetterForMining(ctx, task, fmt.Sprintf("%s, request is missing in event cache", reason))
+		return
+	}
+	if isRequestExpired(event, time.Now()) {
+		pcm.deadLetterForMining(ctx, task, fmt.Sprintf("%s, request deadline has passed", reason))
+		return
+	}
+	pcm.mapMutex.Lock()
+	attempt := pcm.remines[task.RequestID] + 1
+	pcm.remines[task.RequestID] = attempt
+	pcm.mapMutex.Unlock()
+	if attempt > MaxRemineAttempts {
+		pcm.deadLetterForMining(ctx, task, fmt.Sprintf("%s, request was mined again %d times", reason, MaxRemineAttempts))
+		return
+	}
+
+	log := tracing.Logger(ctx)
+	log.Warnf("Result of request %s can't be submitted, mining it again (attempt %d of %d): %s", task.RequestID, attempt, MaxRemineAttempts, reason)
+	election, err := pcm.miner.Elect(ctx)
+	if err != nil {
+		pcm.deadLetterForMining(ctx, task, fmt.Sprintf("%s, failed to draw election: %v", reason, err))
+		return
+	}
+	if election == nil {
+		pcm.deadLetterForMining(ctx, task, fmt.Sprintf("%s, node hasn't won the election to mine it again", reason))
+		return
+	}
+	newTask, anchor, err := pcm.miner.MineTask(ctx, election, event)
+	if err != nil {
+		pcm.deadLetterForMining(ctx, task, fmt.Sprintf("%s, failed to mine it again: %v", reason, err))
+		return
+	}
+	// the round of the stale task is closed, so the new one needs its own consensus id
+	newTask.ConsensusID = fmt.Sprintf("%s/%d", task.RequestID, attempt)
+	if anchor != nil {
+		pcm.reorgs.Track(task.RequestID, *anchor)
+	}
+	if err := pcm.Propose(ctx, *newTask); err != nil {
+		log.Errorf("Failed to propose task of request %s mined again: %v", task.RequestID, err)
+	}
+}
+
+func (pcm *PBFTConsensusManager) forgetRemines(requestID string) {
+	pcm.mapMutex.Lock()
+	delete(pcm.remines, requestID)
+	pcm.mapMutex.Unlock()
+}
+
+// deadLetterForMining moves the task which answer can't be submitted to the dead-letter queue, so it can be mined again
+func (pcm *PBFTConsensusManager) deadLetterForMining(ctx context.Context, task *types2.DioneTask, reason string) {
+	pcm.forgetRemines(task.RequestID)
+	log := tracing.Logger(ctx)
+	log.Errorf("Result of request %s can't be submitted, moving it to dead-letter queue to be mined again: %s", task.RequestID, reason)
+	if pcm.deadLetters == nil {
+		return
+	}
+	dlErr := pcm.deadLetters.Push(&deadletter.Entry{
+		RequestID:     task.RequestID,
+		OriginChain:   task.OriginChain,
+		RequestType:   task.RequestType,
+		RequestParams: task.RequestParams,
+		Stage:         deadletter.StageMining,
+		Error:         reason,
+		TraceID:       tracing.TraceID(ctx),
+	})
+	if dlErr != nil {
+		log.Errorf("Failed to add request %s to dead-letter queue: %v", task.RequestID, dlErr)
+	}
+}
+
+// audit records the task payload the node has signed or voted for into the audit log
+func (pcm *PBFTConsensusManager) audit(kind string, task *types2.DioneTask, signature []byte) {
+	pcm.auditWithContext(kind, task, signature, nil)
+}
+
+// auditWithContext is audit adding specified values to the context of the record
+func (pcm *PBFTConsensusManager) auditWithContext(kind string, task *types2.DioneTask, signature []byte, extra map[string]string) {
+	recordContext := map[string]string{
+		"request_id":   task.RequestID,
+		"consensus_id": task.ConsensusID,
+		"miner":        task.Miner.String(),
+	}
+	for k, v := range extra {
+		recordContext[k] = v
+	}
+	err := pcm.auditLog.Append(kind, task.Payload, signature, recordContext)
+	if err != nil {
+		logrus.Errorf("Failed to write %s of request %s to audit log: %v", kind, task.RequestID, err)
 	}
 }
 
-func (pcm *PBFTConsensusManager) createConsensusInfo(task *types2.DioneTask, isLeader bool) {
+func (pcm *PBFTConsensusManager) createConsensusInfo(ctx context.Context, task *types2.DioneTask, isLeader bool) {
+	pcm.mapMutex.Lock()
+	defer pcm.mapMutex.Unlock()
 	if _, ok := pcm.consensusMap[task.ConsensusID]; !ok {
+		ctx = tracing.WithRequestID(ctx, task.RequestID)
+		ctx, span := tracing.StartSpan(ctx, "consensus",
+			attribute.String("consensus_id", task.ConsensusID),
+			attribute.Bool("leader", isLeader),
+		)
 		pcm.consensusMap[task.ConsensusID] = &Consensus{
 			IsCurrentMinerLeader: isLeader,
 			Task:                 task,
 			Finished:             false,
+			ctx:                  ctx,
+			span:                 span,
+		}
+	}
+}
+
+// traceContext returns the trace context of consensus round to be sent with messages of the round
+func (pcm *PBFTConsensusManager) traceContext(consensusID string) map[string]string {
+	info := pcm.GetConsensusInfo(consensusID)
+	if info == nil {
+		return nil
+	}
+	return tracing.Inject(info.ctx)
+}
+
+// getAgreedRound returns the finished round of the request with the latest answer,
+// the request has several rounds if its stale answer was mined again
+func (pcm *PBFTConsensusManager) getAgreedRound(requestID string) *Consensus {
+	pcm.mapMutex.Lock()
+	var rounds []*Consensus
+	for _, info := range pcm.consensusMap {
+		if info.Task.RequestID == requestID {
+			rounds = append(rounds, info)
+		}
+	}
+	pcm.mapMutex.Unlock()
+
+	var agreed *Consensus
+	for _, info := range rounds {
+		info.mutex.Lock()
+		finished := info.Finished
+		info.mutex.Unlock()
+		if finished && (agreed == nil || info.Task.FetchedAt > agreed.Task.FetchedAt) {
+			agreed = info
 		}
 	}
+	return agreed
 }
 
 func (pcm *PBFTConsensusManager) GetConsensusInfo(consensusID string) *Consensus {
+	pcm.mapMutex.Lock()
+	defer pcm.mapMutex.Unlock()
 	c, ok := pcm.consensusMap[consensusID]
 	if !ok {
 		return nil
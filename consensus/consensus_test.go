@@ -0,0 +1,171 @@
+package consensus
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/audit"
+	"github.com/Secured-Finance/dione/contracts/dioneOracle"
+	"github.com/Secured-Finance/dione/deadletter"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestConsensusManagerShutdown(t *testing.T) {
+	pcm := &PBFTConsensusManager{}
+	assert.True(t, pcm.enter())
+
+	// in-flight round isn't finished in time
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, pcm.Shutdown(ctx))
+
+	assert.False(t, pcm.enter())
+	assert.Error(t, pcm.Propose(context.Background(), types.DioneTask{}))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pcm.inFlight.Done()
+	}()
+	assert.NoError(t, pcm.Shutdown(context.Background()))
+}
+
+// recordingSpan records the status the span is ended with
+type recordingSpan struct {
+	trace.Span
+	status codes.Code
+	ended  bool
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *recordingSpan) End(...trace.SpanOption) { s.ended = true }
+
+func TestCheckExpiredRounds(t *testing.T) {
+	now := time.Now()
+	newRound := func(id string, deadline time.Time, finished bool) *Consensus {
+		c := &Consensus{
+			Task:     &types.DioneTask{ConsensusID: id, Deadline: deadline.Unix()},
+			Finished: finished,
+			ctx:      context.Background(),
+			span:     &recordingSpan{Span: trace.SpanFromContext(context.Background())},
+		}
+		if finished {
+			c.closedAt = now
+		}
+		return c
+	}
+	pcm := &PBFTConsensusManager{msgLog: NewMessageLog(), consensusMap: map[string]*Consensus{
+		"expired":   newRound("expired", now.Add(-time.Minute), false),
+		"finished":  newRound("finished", now.Add(-time.Minute), true),
+		"in_flight": newRound("in_flight", now.Add(time.Minute), false),
+	}}
+
+	pcm.checkExpiredRounds(now)
+	assert.True(t, pcm.GetConsensusInfo("expired").expired)
+	assert.False(t, pcm.GetConsensusInfo("finished").expired)
+	assert.False(t, pcm.GetConsensusInfo("in_flight").expired)
+
+	span := pcm.GetConsensusInfo("expired").span.(*recordingSpan)
+	assert.True(t, span.ended)
+	assert.Equal(t, codes.Error, span.status)
+	assert.False(t, pcm.GetConsensusInfo("in_flight").span.(*recordingSpan).ended)
+
+	// the expired round is pruned with its messages after the retention time
+	pcm.checkExpiredRounds(now.Add(roundRetention + time.Minute))
+	assert.Nil(t, pcm.GetConsensusInfo("expired"))
+	assert.True(t, pcm.msgLog.IsStale("expired"))
+	assert.NotNil(t, pcm.GetConsensusInfo("in_flight"))
+}
+
+func TestPruneRounds(t *testing.T) {
+	now := time.Now()
+	pcm := &PBFTConsensusManager{msgLog: NewMessageLog(), consensusMap: map[string]*Consensus{
+		"old":    {Task: &types.DioneTask{ConsensusID: "old"}, Finished: true, closedAt: now.Add(-roundRetention - time.Minute)},
+		"recent": {Task: &types.DioneTask{ConsensusID: "recent"}, Finished: true, closedAt: now.Add(-time.Minute)},
+		"open":   {Task: &types.DioneTask{ConsensusID: "open"}},
+	}}
+
+	pcm.pruneRounds(now)
+	assert.Nil(t, pcm.GetConsensusInfo("old"))
+	assert.NotNil(t, pcm.GetConsensusInfo("recent"))
+	assert.NotNil(t, pcm.GetConsensusInfo("open"))
+}
+
+// singleEventCache is the event cache with the single request
+type singleEventCache struct {
+	event *dioneOracle.DioneOracleNewOracleRequest
+}
+
+func (c singleEventCache) Store(key string, event interface{}) error { return nil }
+
+func (c singleEventCache) GetOracleRequestEvent(key string) (*dioneOracle.DioneOracleNewOracleRequest, error) {
+	return c.event, nil
+}
+
+func (c singleEventCache) Delete(key string) {}
+
+func TestRemineDeadLetters(t *testing.T) {
+	deadLetters, err := deadletter.NewQueue(filepath.Join(t.TempDir(), "dead_letters.json"))
+	require.NoError(t, err)
+	task := &types.DioneTask{RequestID: "1", ConsensusID: "1"}
+
+	// the request deadline has passed
+	pcm := &PBFTConsensusManager{
+		eventCache:  singleEventCache{&dioneOracle.DioneOracleNewOracleRequest{Deadline: big.NewInt(time.Now().Add(-time.Minute).Unix())}},
+		deadLetters: deadLetters,
+		remines:     map[string]int{},
+	}
+	pcm.remine(context.Background(), task, "task answer is too old")
+	require.NotNil(t, deadLetters.Get("1"))
+	assert.Equal(t, deadletter.StageMining, deadLetters.Get("1").Stage)
+	assert.Contains(t, deadLetters.Get("1").Error, "request deadline has passed")
+	require.NoError(t, deadLetters.Remove("1"))
+
+	// the request was mined again too many times
+	pcm.eventCache = singleEventCache{&dioneOracle.DioneOracleNewOracleRequest{Deadline: big.NewInt(time.Now().Add(time.Minute).Unix())}}
+	pcm.remines["1"] = MaxRemineAttempts
+	pcm.remine(context.Background(), task, "task answer is too old")
+	require.NotNil(t, deadLetters.Get("1"))
+	assert.Contains(t, deadLetters.Get("1").Error, "mined again")
+	assert.Empty(t, pcm.remines)
+}
+
+func TestGetAgreedRound(t *testing.T) {
+	pcm := &PBFTConsensusManager{consensusMap: map[string]*Consensus{
+		"1":   {Task: &types.DioneTask{RequestID: "1", ConsensusID: "1", FetchedAt: 100}, Finished: true},
+		"1/1": {Task: &types.DioneTask{RequestID: "1", ConsensusID: "1/1", FetchedAt: 200}, Finished: true},
+		"1/2": {Task: &types.DioneTask{RequestID: "1", ConsensusID: "1/2", FetchedAt: 300}},
+		"2":   {Task: &types.DioneTask{RequestID: "2", ConsensusID: "2", FetchedAt: 400}, Finished: true},
+	}}
+
+	// the answer mined again is the agreed one, the round in progress isn't
+	assert.Equal(t, "1/1", pcm.getAgreedRound("1").Task.ConsensusID)
+	assert.Equal(t, "2", pcm.getAgreedRound("2").Task.ConsensusID)
+	assert.Nil(t, pcm.getAgreedRound("3"))
+}
+
+func TestAuditWithContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(path)
+	require.NoError(t, err)
+	defer auditLog.Close()
+	pcm := &PBFTConsensusManager{auditLog: auditLog}
+
+	pcm.auditWithContext(audit.KindDisputeVote, &types.DioneTask{RequestID: "1", ConsensusID: "1"}, nil, map[string]string{"vote": "false"})
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	last, err := audit.Verify(f)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), last.Seq)
+	assert.Equal(t, map[string]string{"request_id": "1", "consensus_id": "1", "miner": "", "vote": "false"}, last.Context)
+}
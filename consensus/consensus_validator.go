@@ -1,12 +1,17 @@
 package consensus
 
 import (
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
 	"github.com/Secured-Finance/dione/cache"
 	types2 "github.com/Secured-Finance/dione/consensus/types"
 	"github.com/Secured-Finance/dione/consensus/validation"
+	"github.com/Secured-Finance/dione/sortition"
 	"github.com/Secured-Finance/dione/types"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,6 +32,13 @@ func NewConsensusValidator(ec cache.EventCache, miner *Miner) *ConsensusValidato
 			// TODO here we need to do validation of tx itself
 			consensusMsg := msg.Payload
 
+			// === verify that all fields used below are present ===
+			if err := checkProposedTask(&consensusMsg.Task); err != nil {
+				logrus.Errorf("malformed task: %v", err)
+				return false
+			}
+			/////////////////////////////////
+
 			// === verify task signature ===
 			err := VerifyTaskSignature(consensusMsg.Task)
 			if err != nil {
@@ -35,6 +47,13 @@ func NewConsensusValidator(ec cache.EventCache, miner *Miner) *ConsensusValidato
 			}
 			/////////////////////////////////
 
+			// === verify that the task isn't expired and its answer isn't stale ===
+			if err := cv.miner.staleness.CheckTask(&consensusMsg.Task, time.Now()); err != nil {
+				logrus.Errorf("stale task: %v", err)
+				return false
+			}
+			/////////////////////////////////
+
 			// === verify if request exists in event log cache ===
 			requestEvent, err := cv.eventCache.GetOracleRequestEvent("request_" + consensusMsg.Task.RequestID)
 			if err != nil {
@@ -48,6 +67,14 @@ func NewConsensusValidator(ec cache.EventCache, miner *Miner) *ConsensusValidato
 				logrus.Errorf("the incoming task and cached request event don't match!")
 				return false
 			}
+			if consensusMsg.Task.ConsensusID != consensusMsg.Task.RequestID && !strings.HasPrefix(consensusMsg.Task.ConsensusID, consensusMsg.Task.RequestID+"/") {
+				logrus.Errorf("consensus id %s doesn't belong to request %s", consensusMsg.Task.ConsensusID, consensusMsg.Task.RequestID)
+				return false
+			}
+			if err := checkRequestDeadline(&consensusMsg.Task, requestEvent); err != nil {
+				logrus.Errorf("stale task: %v", err)
+				return false
+			}
 			/////////////////////////////////
 
 			// === verify election proof wincount preliminarily ===
@@ -65,62 +92,21 @@ func NewConsensusValidator(ec cache.EventCache, miner *Miner) *ConsensusValidato
 			}
 			/////////////////////////////////
 
-			// === verify election proof vrf ===
-			minerAddressMarshalled, err := consensusMsg.Task.Miner.MarshalBinary()
-			if err != nil {
-				logrus.Errorf("failed to marshal miner address: %v", err)
-				return false
-			}
-			electionProofRandomness, err := DrawRandomness(
-				consensusMsg.Task.BeaconEntries[1].Data,
-				crypto.DomainSeparationTag_ElectionProofProduction,
-				consensusMsg.Task.DrandRound,
-				minerAddressMarshalled,
-			)
-			if err != nil {
-				logrus.Errorf("failed to draw electionProofRandomness: %v", err)
-				return false
-			}
-			err = VerifyVRF(consensusMsg.Task.Miner, electionProofRandomness, consensusMsg.Task.ElectionProof.VRFProof)
-			if err != nil {
-				logrus.Errorf("failed to verify election proof vrf: %v", err)
-			}
-			//////////////////////////////////////
-
-			// === verify ticket vrf ===
-			ticketRandomness, err := DrawRandomness(
-				consensusMsg.Task.BeaconEntries[1].Data,
-				crypto.DomainSeparationTag_TicketProduction,
-				consensusMsg.Task.DrandRound-types.TicketRandomnessLookback,
-				minerAddressMarshalled,
-			)
-			if err != nil {
-				logrus.Errorf("failed to draw ticket electionProofRandomness: %v", err)
-				return false
-			}
-
-			err = VerifyVRF(consensusMsg.Task.Miner, ticketRandomness, consensusMsg.Task.Ticket.VRFProof)
-			if err != nil {
-				logrus.Errorf("failed to verify ticket vrf: %v", err)
-			}
-			//////////////////////////////////////
-
-			// === compute wincount locally and verify values ===
+			// === verify election proof and ticket vrf and wincount ===
 			mStake, nStake, err := cv.miner.GetStakeInfo(common.HexToAddress(consensusMsg.Task.MinerEth))
 			if err != nil {
 				logrus.Errorf("failed to get miner stake: %v", err)
 				return false
 			}
-			actualWinCount := consensusMsg.Task.ElectionProof.ComputeWinCount(*mStake, *nStake)
-			if consensusMsg.Task.ElectionProof.WinCount != actualWinCount {
-				logrus.Errorf("locally computed wincount isn't matching received value!", err)
+			if err := sortition.Verify(&consensusMsg.Task, *mStake, *nStake); err != nil {
+				logrus.Errorf("failed to verify sortition: %v", err)
 				return false
 			}
 			//////////////////////////////////////
 
 			// === validate payload by specific-chain checks ===
 			if validationFunc := validation.GetValidationMethod(consensusMsg.Task.OriginChain, consensusMsg.Task.RequestType); validationFunc != nil {
-				err := validationFunc(consensusMsg.Task.Payload)
+				err := validationFunc(consensusMsg.Task.RequestParams, consensusMsg.Task.Payload)
 				if err != nil {
 					logrus.Errorf("payload validation has failed: %v", err)
 					return false
@@ -133,17 +119,33 @@ func NewConsensusValidator(ec cache.EventCache, miner *Miner) *ConsensusValidato
 			return true
 		},
 		types2.MessageTypePrepare: func(msg types2.Message) bool {
+			if err := checkTask(&msg.Payload.Task); err != nil {
+				logrus.Debugf("malformed task: %v", err)
+				return false
+			}
 			err := VerifyTaskSignature(msg.Payload.Task)
 			if err != nil {
 				return false
 			}
+			if err := cv.miner.staleness.CheckTask(&msg.Payload.Task, time.Now()); err != nil {
+				logrus.Debugf("stale task: %v", err)
+				return false
+			}
 			return true
 		},
 		types2.MessageTypeCommit: func(msg types2.Message) bool {
+			if err := checkTask(&msg.Payload.Task); err != nil {
+				logrus.Debugf("malformed task: %v", err)
+				return false
+			}
 			err := VerifyTaskSignature(msg.Payload.Task)
 			if err != nil {
 				return false
 			}
+			if err := cv.miner.staleness.CheckTask(&msg.Payload.Task, time.Now()); err != nil {
+				logrus.Debugf("stale task: %v", err)
+				return false
+			}
 			return true
 		},
 	}
@@ -152,5 +154,40 @@ func NewConsensusValidator(ec cache.EventCache, miner *Miner) *ConsensusValidato
 }
 
 func (cv *ConsensusValidator) Valid(msg types2.Message) bool {
-	return cv.validationFuncMap[msg.Type](msg)
+	validationFunc, ok := cv.validationFuncMap[msg.Type]
+	if !ok {
+		return false
+	}
+	return validationFunc(msg)
+}
+
+// checkTask rejects the task without the fields which identify consensus and its signer
+func checkTask(task *types.DioneTask) error {
+	if task.Miner == "" {
+		return xerrors.Errorf("task has no miner")
+	}
+	if task.RequestID == "" || task.ConsensusID == "" {
+		return xerrors.Errorf("task has no request or consensus id")
+	}
+	if len(task.Signature) == 0 {
+		return xerrors.Errorf("task isn't signed")
+	}
+	return nil
+}
+
+// checkProposedTask additionally verifies the mining proofs of the task, so they can be dereferenced safely
+func checkProposedTask(task *types.DioneTask) error {
+	if err := checkTask(task); err != nil {
+		return err
+	}
+	if task.ElectionProof == nil || task.Ticket == nil {
+		return xerrors.Errorf("task has no election proof or ticket")
+	}
+	if len(task.BeaconEntries) != 2 {
+		return xerrors.Errorf("task has %d beacon entries instead of 2", len(task.BeaconEntries))
+	}
+	if task.DrandRound <= types.TicketRandomnessLookback || uint64(task.DrandRound) != task.BeaconEntries[1].Round {
+		return xerrors.Errorf("task drand round %d doesn't match its beacon entries", task.DrandRound)
+	}
+	return nil
 }
@@ -0,0 +1,84 @@
+package consensus
+
+import (
+	"crypto/rand"
+	"testing"
+
+	types2 "github.com/Secured-Finance/dione/consensus/types"
+	"github.com/Secured-Finance/dione/contracts/dioneOracle"
+	_ "github.com/Secured-Finance/dione/sigs/ed25519"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+type emptyEventCache struct{}
+
+func (emptyEventCache) Store(key string, event interface{}) error { return nil }
+
+func (emptyEventCache) GetOracleRequestEvent(key string) (*dioneOracle.DioneOracleNewOracleRequest, error) {
+	return nil, xerrors.Errorf("no event")
+}
+
+func (emptyEventCache) Delete(key string) {}
+
+func newTestPeerID(t *testing.T, keyType int) peer.ID {
+	_, pub, err := crypto.GenerateKeyPairWithReader(keyType, 256, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestConsensusValidatorMalformedMessages(t *testing.T) {
+	cv := NewConsensusValidator(emptyEventCache{}, &Miner{staleness: NewStalenessPolicy(0, 0)})
+	miner := newTestPeerID(t, crypto.Ed25519)
+
+	newTask := func() types.DioneTask {
+		return types.DioneTask{
+			Miner:         miner,
+			RequestID:     "1",
+			ConsensusID:   "1",
+			Ticket:        &types.Ticket{},
+			ElectionProof: &types.ElectionProof{WinCount: 1},
+			BeaconEntries: []types.BeaconEntry{{Round: 9}, {Round: 10}},
+			DrandRound:    10,
+			Signature:     []byte("signature"),
+		}
+	}
+
+	tests := map[string]func(task *types.DioneTask){
+		"no miner":             func(task *types.DioneTask) { task.Miner = "" },
+		"no consensus id":      func(task *types.DioneTask) { task.ConsensusID = "" },
+		"no signature":         func(task *types.DioneTask) { task.Signature = nil },
+		"no election proof":    func(task *types.DioneTask) { task.ElectionProof = nil },
+		"no ticket":            func(task *types.DioneTask) { task.Ticket = nil },
+		"no beacon entries":    func(task *types.DioneTask) { task.BeaconEntries = nil },
+		"single beacon entry":  func(task *types.DioneTask) { task.BeaconEntries = task.BeaconEntries[:1] },
+		"negative drand round": func(task *types.DioneTask) { task.DrandRound = -1 },
+		"wrong drand round":    func(task *types.DioneTask) { task.DrandRound = 1000 },
+		"garbage signature":    func(task *types.DioneTask) {},
+		"secp256k1 miner": func(task *types.DioneTask) {
+			task.Miner = newTestPeerID(t, crypto.Secp256k1)
+		},
+	}
+	for name, mutate := range tests {
+		task := newTask()
+		mutate(&task)
+		for _, typ := range []types2.MessageType{types2.MessageTypePrePrepare, types2.MessageTypePrepare, types2.MessageTypeCommit} {
+			msg := types2.Message{Type: typ, Payload: types2.ConsensusMessage{Task: task}}
+			assert.NotPanics(t, func() {
+				assert.False(t, cv.Valid(msg), name)
+			}, name)
+		}
+	}
+
+	assert.False(t, cv.Valid(types2.Message{Type: types2.MessageTypeUnknown}))
+	assert.False(t, cv.Valid(types2.Message{Type: 42}))
+}
@@ -3,10 +3,12 @@ package consensus
 import (
 	"context"
 	"encoding/hex"
+	"strconv"
 	"time"
 
 	"math/big"
 
+	"github.com/Secured-Finance/dione/audit"
 	"github.com/Secured-Finance/dione/contracts/dioneDispute"
 	"github.com/Secured-Finance/dione/contracts/dioneOracle"
 	"github.com/Secured-Finance/dione/ethclient"
@@ -69,7 +71,7 @@ func NewDisputeManager(ctx context.Context, ethClient *ethclient.EthereumClient,
 }
 
 func (dm *DisputeManager) onNewSubmission(submittion *dioneOracle.DioneOracleSubmittedOracleRequest) {
-	c := dm.pcm.GetConsensusInfo(submittion.ReqID.String())
+	c := dm.pcm.getAgreedRound(submittion.ReqID.String())
 	if c == nil {
 		// todo: warn
 		return
@@ -94,6 +96,7 @@ func (dm *DisputeManager) onNewSubmission(submittion *dioneOracle.DioneOracleSub
 			logrus.Errorf(err.Error())
 			return
 		}
+		dm.pcm.audit(audit.KindDisputeBegin, c.Task, nil)
 		disputeFinishTimer := time.NewTimer(dm.voteWindow)
 		go func() {
 			for {
@@ -122,7 +125,7 @@ func (dm *DisputeManager) onNewSubmission(submittion *dioneOracle.DioneOracleSub
 }
 
 func (dm *DisputeManager) onNewDispute(dispute *dioneDispute.DioneDisputeNewDispute) {
-	c := dm.pcm.GetConsensusInfo(dispute.RequestID.String())
+	c := dm.pcm.getAgreedRound(dispute.RequestID.String())
 	if c == nil {
 		// todo: warn
 		return
@@ -144,17 +147,14 @@ func (dm *DisputeManager) onNewDispute(dispute *dioneDispute.DioneDisputeNewDisp
 	localHashBytes := sha3.Sum256(c.Task.Payload)
 	submHash := hex.EncodeToString(submHashBytes[:])
 	localHash := hex.EncodeToString(localHashBytes[:])
-	if submHash == localHash {
-		err := dm.ethClient.VoteDispute(dispute.Dhash, false)
-		if err != nil {
-			logrus.Errorf(err.Error())
-			return
-		}
-	}
-
-	err := dm.ethClient.VoteDispute(dispute.Dhash, true)
+	// the dispute is supported only if the submitted answer differs from the one agreed locally
+	vote := submHash != localHash
+	err := dm.ethClient.VoteDispute(dispute.Dhash, vote)
 	if err != nil {
 		logrus.Errorf(err.Error())
 		return
 	}
+	dm.pcm.auditWithContext(audit.KindDisputeVote, c.Task, nil, map[string]string{
+		"vote": strconv.FormatBool(vote),
+	})
 }
@@ -0,0 +1,27 @@
+//go:build !byzantine
+// +build !byzantine
+
+package consensus
+
+import (
+	types2 "github.com/Secured-Finance/dione/consensus/types"
+	"github.com/sirupsen/logrus"
+)
+
+// faultInjector is a no-op in regular builds, see fault_injection_byzantine.go
+type faultInjector struct{}
+
+func newFaultInjector(faults []string) *faultInjector {
+	if len(faults) != 0 {
+		logrus.Warn("Fault injection is configured, but the node was built without byzantine tag - ignoring")
+	}
+	return &faultInjector{}
+}
+
+func (fi *faultInjector) prePrepareMessages(msg *types2.Message, privKey []byte) []*types2.Message {
+	return []*types2.Message{msg}
+}
+
+func (fi *faultInjector) withholdVotes() bool {
+	return false
+}
@@ -0,0 +1,76 @@
+//go:build byzantine
+// +build byzantine
+
+package consensus
+
+import (
+	types2 "github.com/Secured-Finance/dione/consensus/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Faults which can be injected into node behaviour in builds with byzantine tag.
+// It's used only for testing how honest nodes detect and tolerate malicious ones.
+const (
+	FaultEquivocate     = "equivocate"      // propose two different payloads for the same consensus
+	FaultInvalidProof   = "invalid_proof"   // corrupt election proof and ticket VRFs
+	FaultCorruptPayload = "corrupt_payload" // modify payload after signing the task
+	FaultWithholdVotes  = "withhold_votes"  // never send prepare and commit messages
+)
+
+type faultInjector struct {
+	faults map[string]bool
+}
+
+func newFaultInjector(faults []string) *faultInjector {
+	fi := &faultInjector{faults: map[string]bool{}}
+	for _, f := range faults {
+		switch f {
+		case FaultEquivocate, FaultInvalidProof, FaultCorruptPayload, FaultWithholdVotes:
+			fi.faults[f] = true
+			logrus.Warnf("Fault injection is enabled: %s", f)
+		default:
+			logrus.Errorf("Unknown fault %s, skipping", f)
+		}
+	}
+	return fi
+}
+
+func (fi *faultInjector) prePrepareMessages(msg *types2.Message, privKey []byte) []*types2.Message {
+	task := &msg.Payload.Task
+	if fi.faults[FaultInvalidProof] {
+		if task.ElectionProof != nil {
+			task.ElectionProof.VRFProof = flipBytes(task.ElectionProof.VRFProof)
+		}
+		if task.Ticket != nil {
+			task.Ticket.VRFProof = flipBytes(task.Ticket.VRFProof)
+		}
+	}
+	if fi.faults[FaultCorruptPayload] {
+		task.Payload = flipBytes(task.Payload)
+	}
+
+	msgs := []*types2.Message{msg}
+	if fi.faults[FaultEquivocate] {
+		conflictingTask := *task
+		conflictingTask.Payload = append(flipBytes(task.Payload), 0xff)
+		conflicting, err := CreatePrePrepareWithTaskSignature(&conflictingTask, privKey)
+		if err != nil {
+			logrus.Errorf("failed to create conflicting pre_prepare msg: %v", err)
+			return msgs
+		}
+		msgs = append(msgs, conflicting)
+	}
+	return msgs
+}
+
+func (fi *faultInjector) withholdVotes() bool {
+	return fi.faults[FaultWithholdVotes]
+}
+
+func flipBytes(b []byte) []byte {
+	res := make([]byte, len(b))
+	for i, v := range b {
+		res[i] = ^v
+	}
+	return res
+}
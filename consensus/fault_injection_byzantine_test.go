@@ -0,0 +1,303 @@
+//go:build byzantine
+// +build byzantine
+
+package consensus
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/audit"
+	types2 "github.com/Secured-Finance/dione/consensus/types"
+	"github.com/Secured-Finance/dione/contracts/dioneOracle"
+	"github.com/Secured-Finance/dione/ethclient"
+	"github.com/Secured-Finance/dione/pubsub"
+	"github.com/Secured-Finance/dione/sigs"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+const (
+	testTopic = "dione_byzantine_test"
+	// testHonestNodes is the count of honest validators, every one of them sees prepare and commit
+	// messages of the others only, so the quorum can't be reached without all of them
+	testHonestNodes  = 4
+	testMinApprovals = testHonestNodes - 1
+	testWaitTimeout  = 15 * time.Second
+)
+
+// wholeStake makes every miner hold the whole stake, so the leader always wins the election
+type wholeStake struct{}
+
+func (wholeStake) GetMinerStake(common.Address) (*types.BigInt, error) {
+	stake := types.NewInt(ethclient.MinMinerStake)
+	return &stake, nil
+}
+
+func (wholeStake) GetTotalStake() (*types.BigInt, error) {
+	stake := types.NewInt(ethclient.MinMinerStake)
+	return &stake, nil
+}
+
+// requestEvents is the event cache with requests registered before the network starts
+type requestEvents map[string]*dioneOracle.DioneOracleNewOracleRequest
+
+func (e requestEvents) Store(key string, event interface{}) error { return nil }
+
+func (e requestEvents) GetOracleRequestEvent(key string) (*dioneOracle.DioneOracleNewOracleRequest, error) {
+	event, ok := e[key]
+	if !ok {
+		return nil, xerrors.Errorf("no event %s", key)
+	}
+	return event, nil
+}
+
+func (e requestEvents) Delete(key string) {}
+
+type testNode struct {
+	pcm      *PBFTConsensusManager
+	auditLog string
+}
+
+// testNetwork runs honest consensus managers and optionally the byzantine one connected by the mock network.
+// The leader isn't run as a consensus manager, since it would submit the agreed result on-chain,
+// its pre_prepare messages are broadcast directly instead.
+type testNetwork struct {
+	leader    *Miner
+	leaderPsb *pubsub.PubSubRouter
+	honest    []*testNode
+	byzantine *testNode
+}
+
+func newTestNetwork(t *testing.T, events requestEvents, byzantineFaults []string) *testNetwork {
+	ctx, cancel := context.WithCancel(context.Background())
+	mn := mocknet.New(ctx)
+	t.Cleanup(cancel)
+
+	nodeCount := testHonestNodes + 1
+	if byzantineFaults != nil {
+		nodeCount++
+	}
+	var routers []*pubsub.PubSubRouter
+	var miners []*Miner
+	for i := 0; i < nodeCount; i++ {
+		privKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		require.NoError(t, err)
+		h, err := mn.AddPeer(privKey, newTestMultiaddr(t, i))
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		rawKey, err := privKey.Raw()
+		require.NoError(t, err)
+
+		psb := pubsub.NewPubSubRouter(h, testTopic, false, nil)
+		t.Cleanup(psb.Shutdown)
+		routers = append(routers, psb)
+		miners = append(miners, &Miner{
+			address:    h.ID(),
+			privateKey: rawKey,
+			ethClient:  wholeStake{},
+			staleness:  NewStalenessPolicy(0, 0),
+		})
+	}
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+	for _, psb := range routers {
+		psb := psb
+		require.Eventually(t, func() bool {
+			return len(psb.Pubsub.ListPeers(testTopic)) == nodeCount-1
+		}, testWaitTimeout, 50*time.Millisecond, "peers haven't joined the topic")
+	}
+
+	newNode := func(i int, faults []string) *testNode {
+		auditPath := filepath.Join(t.TempDir(), "audit.log")
+		auditLog, err := audit.Open(auditPath)
+		require.NoError(t, err)
+		t.Cleanup(func() { auditLog.Close() })
+		pcm := NewPBFTConsensusManager(routers[i], testMinApprovals, miners[i].privateKey, nil, miners[i], events, faults, nil, nil, auditLog, nil)
+		return &testNode{pcm: pcm, auditLog: auditPath}
+	}
+	n := &testNetwork{leader: miners[0], leaderPsb: routers[0]}
+	for i := 1; i <= testHonestNodes; i++ {
+		n.honest = append(n.honest, newNode(i, nil))
+	}
+	if byzantineFaults != nil {
+		n.byzantine = newNode(nodeCount-1, byzantineFaults)
+	}
+	return n
+}
+
+func newTestMultiaddr(t *testing.T, i int) ma.Multiaddr {
+	a, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 4000+i))
+	require.NoError(t, err)
+	return a
+}
+
+// newTestRequest registers the oracle request the leader will propose the task for
+func newTestRequest(events requestEvents, requestID int64) {
+	events[fmt.Sprintf("request_%d", requestID)] = &dioneOracle.DioneOracleNewOracleRequest{
+		OriginChain:   1,
+		RequestType:   "byzantine_test",
+		RequestParams: "params",
+		ReqID:         big.NewInt(requestID),
+	}
+}
+
+// propose builds the signed pre_prepare message of the task won by the leader
+func (n *testNetwork) propose(t *testing.T, requestID int64) *types2.Message {
+	beaconEntries := []types.BeaconEntry{
+		{Round: 9, Data: []byte("previous beacon")},
+		{Round: 10, Data: []byte(fmt.Sprintf("beacon of request %d", requestID))},
+	}
+	ticket, err := n.leader.computeTicket(&beaconEntries[1])
+	require.NoError(t, err)
+	stake := types.NewInt(ethclient.MinMinerStake)
+	proof, err := IsRoundWinner(10, n.leader.address, beaconEntries[1], stake, stake, func(id peer.ID, data []byte) (*types.Signature, error) {
+		return sigs.Sign(types.SigTypeEd25519, n.leader.privateKey, data)
+	})
+	require.NoError(t, err)
+	require.NotNil(t, proof, "leader holding the whole stake must win")
+
+	task := &types.DioneTask{
+		OriginChain:   1,
+		RequestType:   "byzantine_test",
+		RequestParams: "params",
+		RequestID:     fmt.Sprint(requestID),
+		ConsensusID:   fmt.Sprint(requestID),
+		Miner:         n.leader.address,
+		Ticket:        ticket,
+		ElectionProof: proof,
+		BeaconEntries: beaconEntries,
+		Payload:       []byte("answer"),
+		DrandRound:    10,
+	}
+	n.leader.staleness.Stamp(task, time.Now(), nil)
+	msg, err := CreatePrePrepareWithTaskSignature(task, n.leader.privateKey)
+	require.NoError(t, err)
+	return msg
+}
+
+func (n *testNetwork) broadcast(t *testing.T, msgs ...*types2.Message) {
+	for _, msg := range msgs {
+		require.NoError(t, n.leaderPsb.BroadcastToServiceTopic(msg))
+	}
+}
+
+// waitFinished waits until all honest nodes have committed the consensus
+func (n *testNetwork) waitFinished(t *testing.T, consensusID string) {
+	for i, node := range n.honest {
+		node := node
+		assert.Eventually(t, func() bool {
+			info := node.pcm.GetConsensusInfo(consensusID)
+			if info == nil {
+				return false
+			}
+			info.mutex.Lock()
+			defer info.mutex.Unlock()
+			return info.Finished
+		}, testWaitTimeout, 50*time.Millisecond, "honest node %d hasn't committed consensus %s", i, consensusID)
+	}
+}
+
+// readAudit returns audit records of the node for specified consensus
+func readAudit(t *testing.T, node *testNode, consensusID string) []*audit.Record {
+	f, err := os.Open(node.auditLog)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []*audit.Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r audit.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		if r.Context["consensus_id"] == consensusID {
+			records = append(records, &r)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func countKind(records []*audit.Record, kind string) int {
+	count := 0
+	for _, r := range records {
+		if r.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// testRejectedProposal checks that honest nodes drop the faulty proposal and still commit the next honest one
+func testRejectedProposal(t *testing.T, fault string) {
+	events := requestEvents{}
+	newTestRequest(events, 1)
+	newTestRequest(events, 2)
+	n := newTestNetwork(t, events, nil)
+
+	faulty := newFaultInjector([]string{fault}).prePrepareMessages(n.propose(t, 1), n.leader.privateKey)
+	n.broadcast(t, faulty...)
+	n.broadcast(t, n.propose(t, 2))
+
+	n.waitFinished(t, "2")
+	for i, node := range n.honest {
+		assert.Nil(t, node.pcm.GetConsensusInfo("1"), "honest node %d has accepted the proposal", i)
+		assert.Empty(t, readAudit(t, node, "1"), "honest node %d has voted for the proposal", i)
+	}
+}
+
+func TestByzantineInvalidProof(t *testing.T) {
+	testRejectedProposal(t, FaultInvalidProof)
+}
+
+func TestByzantineCorruptPayload(t *testing.T) {
+	testRejectedProposal(t, FaultCorruptPayload)
+}
+
+func TestByzantineEquivocate(t *testing.T) {
+	events := requestEvents{}
+	newTestRequest(events, 1)
+	n := newTestNetwork(t, events, nil)
+
+	msgs := newFaultInjector([]string{FaultEquivocate}).prePrepareMessages(n.propose(t, 1), n.leader.privateKey)
+	require.Len(t, msgs, 2)
+	require.NoError(t, VerifyTaskSignature(msgs[1].Payload.Task), "conflicting proposal must be validly signed")
+	n.broadcast(t, msgs...)
+
+	// every honest node votes for one of the conflicting proposals only, and the round is still committed
+	n.waitFinished(t, "1")
+	for i, node := range n.honest {
+		assert.Equal(t, 1, countKind(readAudit(t, node, "1"), audit.KindPrepare), "honest node %d has prepared conflicting proposals", i)
+	}
+}
+
+func TestByzantineWithholdVotes(t *testing.T) {
+	events := requestEvents{}
+	newTestRequest(events, 1)
+	n := newTestNetwork(t, events, []string{FaultWithholdVotes})
+
+	n.broadcast(t, n.propose(t, 1))
+
+	n.waitFinished(t, "1")
+	for i, node := range n.honest {
+		records := readAudit(t, node, "1")
+		assert.Equal(t, 1, countKind(records, audit.KindPrepare), "honest node %d", i)
+		assert.Equal(t, 1, countKind(records, audit.KindCommit), "honest node %d", i)
+	}
+	assert.NotNil(t, n.byzantine.pcm.GetConsensusInfo("1"), "byzantine node hasn't received the proposal")
+	assert.Empty(t, readAudit(t, n.byzantine, "1"), "byzantine node has voted")
+}
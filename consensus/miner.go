@@ -3,12 +3,17 @@ package consensus
 import (
 	"context"
 	"sync"
+	"time"
 
 	big2 "github.com/filecoin-project/go-state-types/big"
 
 	"github.com/Secured-Finance/dione/sigs"
 
+	"github.com/Secured-Finance/dione/consensus/validation"
+	"github.com/Secured-Finance/dione/reorg"
 	"github.com/Secured-Finance/dione/rpc"
+	"github.com/Secured-Finance/dione/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/Secured-Finance/dione/beacon"
 	"github.com/Secured-Finance/dione/contracts/dioneOracle"
@@ -22,15 +27,23 @@ import (
 	"golang.org/x/xerrors"
 )
 
+// stakeSource returns stakes of miners, it's implemented by ethclient.EthereumClient
+type stakeSource interface {
+	GetMinerStake(minerAddress common.Address) (*types.BigInt, error)
+	GetTotalStake() (*types.BigInt, error)
+}
+
 type Miner struct {
 	address      peer.ID
 	ethAddress   common.Address
 	mutex        sync.Mutex
 	beacon       beacon.BeaconNetworks
-	ethClient    *ethclient.EthereumClient
+	ethClient    stakeSource
 	minerStake   types.BigInt
 	networkStake types.BigInt
 	privateKey   []byte
+	staleness    StalenessPolicy
+	fetches      *rpc.Scheduler
 }
 
 func NewMiner(
@@ -39,6 +52,8 @@ func NewMiner(
 	beacon beacon.BeaconNetworks,
 	ethClient *ethclient.EthereumClient,
 	privateKey []byte,
+	staleness StalenessPolicy,
+	fetches *rpc.Scheduler,
 ) *Miner {
 	return &Miner{
 		address:    address,
@@ -46,6 +61,8 @@ func NewMiner(
 		beacon:     beacon,
 		ethClient:  ethClient,
 		privateKey: privateKey,
+		staleness:  staleness,
+		fetches:    fetches,
 	}
 }
 
@@ -88,7 +105,17 @@ func (m *Miner) GetStakeInfo(miner common.Address) (*types.BigInt, *types.BigInt
 	return mStake, nStake, nil
 }
 
-func (m *Miner) MineTask(ctx context.Context, event *dioneOracle.DioneOracleNewOracleRequest) (*types.DioneTask, error) {
+// Election is the outcome of leader election won by the node. It's drawn once per request,
+// so retries of the fetch can't redraw it with fresh beacon entries.
+type Election struct {
+	Ticket        *types.Ticket
+	Proof         *types.ElectionProof
+	BeaconEntries []types.BeaconEntry
+	Round         types.DrandRound
+}
+
+// Elect draws the leader election with the latest beacon entries, it returns nil if the node isn't the leader
+func (m *Miner) Elect(ctx context.Context) (*Election, error) {
 	beaconValues, err := beacon.BeaconEntriesForTask(ctx, m.beacon)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to get beacon entries: %w", err)
@@ -123,17 +150,59 @@ func (m *Miner) MineTask(ctx context.Context, event *dioneOracle.DioneOracleNewO
 	if winner == nil {
 		return nil, nil
 	}
+	return &Election{
+		Ticket:        ticket,
+		Proof:         winner,
+		BeaconEntries: beaconValues,
+		Round:         types.DrandRound(randomBase.Round),
+	}, nil
+}
 
-	rpcMethod := rpc.GetRPCMethod(event.OriginChain, event.RequestType)
+// MineTask fetches the answer to the request and builds the task of the won election.
+// It can be retried on fetch failures without drawing the election again.
+// The returned anchor is the source chain block the answer is based on, it's nil
+// if the answer isn't based on a block which can be reorganized.
+func (m *Miner) MineTask(ctx context.Context, election *Election, event *dioneOracle.DioneOracleNewOracleRequest) (*types.DioneTask, *reorg.Anchor, error) {
+	rpcMethod := rpc.GetAnchoredRPCMethod(event.OriginChain, event.RequestType)
+	if rpcMethod == nil {
+		if method := rpc.GetRPCMethod(event.OriginChain, event.RequestType); method != nil {
+			rpcMethod = func(params string) ([]byte, *reorg.Anchor, error) {
+				res, err := method(params)
+				return res, nil, err
+			}
+		}
+	}
 	if rpcMethod == nil {
-		return nil, xerrors.Errorf("invalid rpc method name/type")
+		return nil, nil, xerrors.Errorf("invalid rpc method name/type")
 	}
-	res, err := rpcMethod(event.RequestParams)
+	fetchCtx, span := tracing.StartSpan(ctx, "fetch",
+		attribute.Int("origin_chain", int(event.OriginChain)),
+		attribute.String("request_type", event.RequestType),
+	)
+	// the answer can't be agreed after the task deadline, so waiting for the fetch longer is useless
+	fetchCtx, cancel := context.WithTimeout(fetchCtx, m.staleness.TaskDeadline)
+	defer cancel()
+	var anchor *reorg.Anchor
+	res, err := m.fetches.Do(fetchCtx, event.OriginChain, event.RequestType, func() ([]byte, error) {
+		res, a, err := rpcMethod(event.RequestParams)
+		anchor = a
+		return res, err
+	})
 	if err != nil {
-		return nil, xerrors.Errorf("couldn't do rpc request: %w", err)
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, nil, xerrors.Errorf("couldn't do rpc request: %w", err)
 	}
+	span.End()
 
-	return &types.DioneTask{
+	// reject malformed or truncated responses before they are signed into the task
+	if validationFunc := validation.GetValidationMethod(event.OriginChain, event.RequestType); validationFunc != nil {
+		if err := validationFunc(event.RequestParams, res); err != nil {
+			return nil, nil, xerrors.Errorf("rpc response has failed validation: %w", err)
+		}
+	}
+
+	task := &types.DioneTask{
 		OriginChain:   event.OriginChain,
 		RequestType:   event.RequestType,
 		RequestParams: event.RequestParams,
@@ -141,12 +210,15 @@ func (m *Miner) MineTask(ctx context.Context, event *dioneOracle.DioneOracleNewO
 		ConsensusID:   event.ReqID.String(),
 		Miner:         m.address,
 		MinerEth:      m.ethAddress.Hex(),
-		Ticket:        ticket,
-		ElectionProof: winner,
-		BeaconEntries: beaconValues,
+		Ticket:        election.Ticket,
+		ElectionProof: election.Proof,
+		BeaconEntries: election.BeaconEntries,
 		Payload:       res,
-		DrandRound:    types.DrandRound(randomBase.Round),
-	}, nil
+		DrandRound:    election.Round,
+	}
+	m.staleness.Stamp(task, time.Now(), event.Deadline)
+
+	return task, anchor, nil
 }
 
 func (m *Miner) computeTicket(brand *types.BeaconEntry) (*types.Ticket, error) {
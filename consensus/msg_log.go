@@ -1,39 +1,143 @@
 package consensus
 
 import (
+	"sync"
+
 	types2 "github.com/Secured-Finance/dione/consensus/types"
 	mapset "github.com/Secured-Finance/golang-set"
 )
 
+// DefaultReplayWindow is the count of the most recent consensus instances the message log keeps track of
+const DefaultReplayWindow = 1024
+
 type MessageLog struct {
-	messages          mapset.Set
+	mutex             sync.RWMutex
+	messages          map[string]mapset.Set // consensusID -> messages
+	consensusOrder    []string
+	closed            map[string]struct{}
+	closedOrder       []string
 	maxLogSize        int
 	validationFuncMap map[types2.MessageType]func(message types2.Message)
 }
 
 func NewMessageLog() *MessageLog {
 	msgLog := &MessageLog{
-		messages:   mapset.NewSet(),
-		maxLogSize: 0, // TODO
+		messages:   map[string]mapset.Set{},
+		closed:     map[string]struct{}{},
+		maxLogSize: DefaultReplayWindow,
 	}
 
 	return msgLog
 }
 
 func (ml *MessageLog) AddMessage(msg types2.Message) {
-	ml.messages.Add(msg)
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+
+	ml.addMessage(msg)
+}
+
+// AddPrePrepare adds the pre_prepare message unless the consensus already has another one and reports whether
+// it was added. Different pre_prepare messages of a single consensus mean the miner equivocates.
+func (ml *MessageLog) AddPrePrepare(msg types2.Message) bool {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+
+	if set, ok := ml.messages[msg.Payload.Task.ConsensusID]; ok && !set.Contains(msg) {
+		conflicting := false
+		// the iteration must not be interrupted, the set stays locked until it's done
+		for v := range set.Iter() {
+			if v.(types2.Message).Type == types2.MessageTypePrePrepare {
+				conflicting = true
+			}
+		}
+		if conflicting {
+			return false
+		}
+	}
+	ml.addMessage(msg)
+	return true
+}
+
+func (ml *MessageLog) addMessage(msg types2.Message) {
+	consensusID := msg.Payload.Task.ConsensusID
+	if _, ok := ml.closed[consensusID]; ok {
+		return
+	}
+	set, ok := ml.messages[consensusID]
+	if !ok {
+		set = mapset.NewSet()
+		ml.messages[consensusID] = set
+		ml.consensusOrder = append(ml.consensusOrder, consensusID)
+		if len(ml.consensusOrder) > ml.maxLogSize {
+			ml.closeConsensus(ml.consensusOrder[0])
+		}
+	}
+	set.Add(msg)
 }
 
 func (ml *MessageLog) Exists(msg types2.Message) bool {
-	return ml.messages.Contains(msg)
+	ml.mutex.RLock()
+	defer ml.mutex.RUnlock()
+
+	set, ok := ml.messages[msg.Payload.Task.ConsensusID]
+	if !ok {
+		return false
+	}
+	return set.Contains(msg)
+}
+
+// IsStale reports whether the consensus is already finished or fell out of the replay window,
+// so any further messages for it must be dropped
+func (ml *MessageLog) IsStale(consensusID string) bool {
+	ml.mutex.RLock()
+	defer ml.mutex.RUnlock()
+
+	_, ok := ml.closed[consensusID]
+	return ok
+}
+
+// Close drops messages of finished consensus and marks it as stale
+func (ml *MessageLog) Close(consensusID string) {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+
+	ml.closeConsensus(consensusID)
+}
+
+func (ml *MessageLog) closeConsensus(consensusID string) {
+	if _, ok := ml.closed[consensusID]; ok {
+		return
+	}
+	delete(ml.messages, consensusID)
+	for i, v := range ml.consensusOrder {
+		if v == consensusID {
+			ml.consensusOrder = append(ml.consensusOrder[:i], ml.consensusOrder[i+1:]...)
+			break
+		}
+	}
+
+	ml.closed[consensusID] = struct{}{}
+	ml.closedOrder = append(ml.closedOrder, consensusID)
+	if len(ml.closedOrder) > ml.maxLogSize {
+		delete(ml.closed, ml.closedOrder[0])
+		ml.closedOrder = ml.closedOrder[1:]
+	}
 }
 
 func (ml *MessageLog) GetMessagesByTypeAndConsensusID(typ types2.MessageType, consensusID string) []types2.Message {
+	ml.mutex.RLock()
+	defer ml.mutex.RUnlock()
+
 	var result []types2.Message
 
-	for v := range ml.messages.Iter() {
+	set, ok := ml.messages[consensusID]
+	if !ok {
+		return result
+	}
+	for v := range set.Iter() {
 		msg := v.(types2.Message)
-		if msg.Type == typ && msg.Payload.Task.ConsensusID == consensusID {
+		if msg.Type == typ {
 			result = append(result, msg)
 		}
 	}
@@ -0,0 +1,62 @@
+package consensus
+
+import (
+	"strconv"
+	"testing"
+
+	types2 "github.com/Secured-Finance/dione/consensus/types"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMessage(typ types2.MessageType, consensusID string) types2.Message {
+	return types2.Message{
+		Type:    typ,
+		Payload: types2.ConsensusMessage{Task: types.DioneTask{ConsensusID: consensusID}},
+	}
+}
+
+func TestMessageLogClose(t *testing.T) {
+	ml := NewMessageLog()
+	msg := newTestMessage(types2.MessageTypePrepare, "1")
+
+	ml.AddMessage(msg)
+	assert.True(t, ml.Exists(msg))
+	assert.Len(t, ml.GetMessagesByTypeAndConsensusID(types2.MessageTypePrepare, "1"), 1)
+	assert.Len(t, ml.GetMessagesByTypeAndConsensusID(types2.MessageTypeCommit, "1"), 0)
+
+	ml.Close("1")
+	assert.True(t, ml.IsStale("1"))
+	assert.False(t, ml.Exists(msg))
+
+	ml.AddMessage(msg)
+	assert.False(t, ml.Exists(msg))
+}
+
+func TestMessageLogReplayWindow(t *testing.T) {
+	ml := NewMessageLog()
+	ml.maxLogSize = 2
+
+	for i := 0; i < 3; i++ {
+		ml.AddMessage(newTestMessage(types2.MessageTypePrepare, strconv.Itoa(i)))
+	}
+
+	assert.True(t, ml.IsStale("0"))
+	assert.False(t, ml.IsStale("1"))
+	assert.False(t, ml.IsStale("2"))
+	assert.True(t, ml.Exists(newTestMessage(types2.MessageTypePrepare, "2")))
+}
+
+func TestMessageLogConflictingPrePrepare(t *testing.T) {
+	ml := NewMessageLog()
+	msg := newTestMessage(types2.MessageTypePrePrepare, "1")
+	conflicting := newTestMessage(types2.MessageTypePrePrepare, "1")
+	conflicting.Payload.Task.Payload = []byte("other")
+
+	ml.AddMessage(newTestMessage(types2.MessageTypePrepare, "1"))
+	assert.True(t, ml.AddPrePrepare(msg))
+	assert.True(t, ml.AddPrePrepare(msg))
+	assert.False(t, ml.AddPrePrepare(conflicting))
+	assert.False(t, ml.Exists(conflicting))
+	assert.True(t, ml.AddPrePrepare(newTestMessage(types2.MessageTypePrePrepare, "2")))
+}
@@ -0,0 +1,91 @@
+package consensus
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Secured-Finance/dione/contracts/dioneOracle"
+	"github.com/Secured-Finance/dione/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultTaskDeadline = 5 * time.Minute
+	// DefaultMaxAnswerAge is shorter than the task deadline on purpose: the answer which gets too old
+	// before submission is fetched again, while the deadline of the request allows it
+	DefaultMaxAnswerAge = 2 * time.Minute
+	// maxClockSkew is the tolerated difference between clocks of the miner and validators
+	maxClockSkew = 30 * time.Second
+)
+
+// StalenessPolicy protects consumers from stale answers: the task must complete
+// consensus and be submitted before its deadline and before the answer gets too old.
+type StalenessPolicy struct {
+	TaskDeadline time.Duration
+	MaxAnswerAge time.Duration
+}
+
+// NewStalenessPolicy creates the policy from config values in secs, zero values mean defaults
+func NewStalenessPolicy(taskDeadline, maxAnswerAge int) StalenessPolicy {
+	p := StalenessPolicy{
+		TaskDeadline: time.Duration(taskDeadline) * time.Second,
+		MaxAnswerAge: time.Duration(maxAnswerAge) * time.Second,
+	}
+	if p.TaskDeadline <= 0 {
+		p.TaskDeadline = DefaultTaskDeadline
+	}
+	if p.MaxAnswerAge <= 0 {
+		p.MaxAnswerAge = DefaultMaxAnswerAge
+	}
+	return p
+}
+
+// Stamp sets fetch time and deadline of the task which payload was just fetched.
+// The deadline is the task deadline since the fetch, or the on-chain deadline of the request if it's earlier.
+func (p StalenessPolicy) Stamp(task *types.DioneTask, fetchedAt time.Time, requestDeadline *big.Int) {
+	task.FetchedAt = fetchedAt.Unix()
+	task.Deadline = fetchedAt.Add(p.TaskDeadline).Unix()
+	if hasRequestDeadline(requestDeadline) && requestDeadline.Int64() < task.Deadline {
+		task.Deadline = requestDeadline.Int64()
+	}
+}
+
+// hasRequestDeadline reports whether the request has on-chain deadline, zero deadline means there is none
+func hasRequestDeadline(deadline *big.Int) bool {
+	return deadline != nil && deadline.Sign() > 0 && deadline.IsInt64()
+}
+
+// checkRequestDeadline returns an error if the task deadline is later than the on-chain deadline of its request
+func checkRequestDeadline(task *types.DioneTask, event *dioneOracle.DioneOracleNewOracleRequest) error {
+	if hasRequestDeadline(event.Deadline) && task.Deadline > event.Deadline.Int64() {
+		return xerrors.Errorf("task deadline %s is later than request deadline %s", time.Unix(task.Deadline, 0), time.Unix(event.Deadline.Int64(), 0))
+	}
+	return nil
+}
+
+// isRequestExpired reports whether the on-chain deadline of the request has passed at specified time
+func isRequestExpired(event *dioneOracle.DioneOracleNewOracleRequest, now time.Time) bool {
+	return hasRequestDeadline(event.Deadline) && now.After(time.Unix(event.Deadline.Int64(), 0))
+}
+
+// CheckTask returns an error if the task is expired or its answer is too old at specified time
+func (p StalenessPolicy) CheckTask(task *types.DioneTask, now time.Time) error {
+	if task.FetchedAt == 0 || task.Deadline == 0 {
+		return xerrors.Errorf("task doesn't have fetch time or deadline")
+	}
+	fetchedAt := time.Unix(task.FetchedAt, 0)
+	deadline := time.Unix(task.Deadline, 0)
+	if fetchedAt.After(now.Add(maxClockSkew)) {
+		return xerrors.Errorf("task answer is fetched in the future at %s", fetchedAt)
+	}
+	if deadline.Sub(fetchedAt) > p.TaskDeadline {
+		return xerrors.Errorf("task deadline %s is too far from fetch time %s", deadline, fetchedAt)
+	}
+	if now.After(deadline) {
+		return xerrors.Errorf("task is expired at %s", deadline)
+	}
+	if age := now.Sub(fetchedAt); age > p.MaxAnswerAge {
+		return xerrors.Errorf("task answer is too old: fetched %s ago", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/contracts/dioneOracle"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStalenessPolicy(t *testing.T) {
+	p := NewStalenessPolicy(60, 30)
+	now := time.Now()
+
+	var task types.DioneTask
+	assert.Error(t, p.CheckTask(&task, now))
+
+	p.Stamp(&task, now, nil)
+	assert.NoError(t, p.CheckTask(&task, now))
+	assert.NoError(t, p.CheckTask(&task, now.Add(20*time.Second)))
+
+	// answer is too old
+	assert.Error(t, p.CheckTask(&task, now.Add(40*time.Second)))
+	// task is expired
+	assert.Error(t, p.CheckTask(&task, now.Add(2*time.Minute)))
+	// answer is fetched in the future
+	assert.Error(t, p.CheckTask(&task, now.Add(-time.Minute)))
+
+	// miner can't extend the deadline
+	task.Deadline = now.Add(time.Hour).Unix()
+	assert.Error(t, p.CheckTask(&task, now))
+}
+
+func TestStalenessPolicyRequestDeadline(t *testing.T) {
+	p := NewStalenessPolicy(60, 30)
+	now := time.Now()
+	event := &dioneOracle.DioneOracleNewOracleRequest{Deadline: big.NewInt(now.Add(20 * time.Second).Unix())}
+
+	// the request deadline is earlier than the task deadline
+	var task types.DioneTask
+	p.Stamp(&task, now, event.Deadline)
+	assert.Equal(t, event.Deadline.Int64(), task.Deadline)
+	assert.NoError(t, checkRequestDeadline(&task, event))
+	assert.Error(t, p.CheckTask(&task, now.Add(25*time.Second)))
+
+	// miner can't extend the request deadline
+	p.Stamp(&task, now, nil)
+	assert.Error(t, checkRequestDeadline(&task, event))
+
+	// zero request deadline means there is none
+	p.Stamp(&task, now, big.NewInt(0))
+	assert.Equal(t, now.Add(time.Minute).Unix(), task.Deadline)
+	assert.NoError(t, checkRequestDeadline(&task, &dioneOracle.DioneOracleNewOracleRequest{Deadline: big.NewInt(0)}))
+
+	assert.False(t, isRequestExpired(event, now))
+	assert.True(t, isRequestExpired(event, now.Add(time.Minute)))
+	assert.False(t, isRequestExpired(&dioneOracle.DioneOracleNewOracleRequest{}, now))
+}
@@ -22,5 +22,7 @@ type ConsensusMessage struct {
 type Message struct {
 	Type    MessageType
 	Payload ConsensusMessage
-	From    peer.ID `cbor:"-"`
+	Trace   map[string]string `cbor:",omitempty" hashset:"-"` // trace context of the sender, it isn't signed
+	From    peer.ID           `cbor:"-"`
+	Raw     []byte            `cbor:"-" hashset:"-"` // message as it was received from the network
 }
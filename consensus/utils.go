@@ -1,7 +1,6 @@
 package consensus
 
 import (
-	"encoding/binary"
 	"fmt"
 
 	types2 "github.com/Secured-Finance/dione/consensus/types"
@@ -9,7 +8,7 @@ import (
 	"github.com/mitchellh/hashstructure/v2"
 
 	"github.com/Secured-Finance/dione/sigs"
-	"github.com/minio/blake2b-simd"
+	"github.com/Secured-Finance/dione/sortition"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 
@@ -34,12 +33,7 @@ func ComputeVRF(sign SignFunc, worker peer.ID, sigInput []byte) ([]byte, error)
 }
 
 func VerifyVRF(worker peer.ID, vrfBase, vrfproof []byte) error {
-	err := sigs.Verify(&types.Signature{Type: types.SigTypeEd25519, Data: vrfproof}, []byte(worker), vrfBase)
-	if err != nil {
-		return xerrors.Errorf("vrf was invalid: %w", err)
-	}
-
-	return nil
+	return sortition.VerifyVRF(worker, vrfBase, vrfproof)
 }
 
 func IsRoundWinner(round types.DrandRound,
@@ -71,24 +65,7 @@ func IsRoundWinner(round types.DrandRound,
 }
 
 func DrawRandomness(rbase []byte, pers crypto.DomainSeparationTag, round types.DrandRound, entropy []byte) ([]byte, error) {
-	h := blake2b.New256()
-	if err := binary.Write(h, binary.BigEndian, int64(pers)); err != nil {
-		return nil, xerrors.Errorf("deriving randomness: %v", err)
-	}
-	VRFDigest := blake2b.Sum256(rbase)
-	_, err := h.Write(VRFDigest[:])
-	if err != nil {
-		return nil, xerrors.Errorf("hashing VRFDigest: %w", err)
-	}
-	if err := binary.Write(h, binary.BigEndian, round); err != nil {
-		return nil, xerrors.Errorf("deriving randomness: %v", err)
-	}
-	_, err = h.Write(entropy)
-	if err != nil {
-		return nil, xerrors.Errorf("hashing entropy: %v", err)
-	}
-
-	return h.Sum(nil), nil
+	return sortition.DrawRandomness(rbase, pers, round, entropy)
 }
 
 func VerifyTaskSignature(task types.DioneTask) error {
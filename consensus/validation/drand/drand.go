@@ -0,0 +1,13 @@
+package drand
+
+import (
+	"github.com/Secured-Finance/dione/consensus/validation"
+	"github.com/Secured-Finance/dione/drand"
+	rtypes "github.com/Secured-Finance/dione/rpc/types"
+)
+
+func init() {
+	validation.RegisterValidation(rtypes.RPCTypeDrand, map[string]func(string, []byte) error{
+		"getRandomness": drand.VerifyRandomnessProof,
+	})
+}
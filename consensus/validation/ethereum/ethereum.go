@@ -0,0 +1,82 @@
+package ethereum
+
+import (
+	"context"
+	"time"
+
+	"github.com/Secured-Finance/dione/consensus/validation"
+	"github.com/Secured-Finance/dione/rpc/ethereum"
+	rtypes "github.com/Secured-Finance/dione/rpc/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/xerrors"
+)
+
+func ValidateGetTransaction(_ string, payload []byte) error {
+	var tx types.Transaction
+	if err := tx.UnmarshalJSON(payload); err != nil {
+		return xerrors.Errorf("cannot unmarshal payload: %w", err)
+	}
+	return nil
+}
+
+func ValidateTokenAmount(_ string, payload []byte) error {
+	if len(payload) != ethereum.TokenAmountSize {
+		return xerrors.Errorf("token amount must be %d bytes long, got %d", ethereum.TokenAmountSize, len(payload))
+	}
+	return nil
+}
+
+// chainCheckTimeout bounds the time of checking the proof against the ethereum node
+const chainCheckTimeout = 30 * time.Second
+
+// ChainChecker checks inclusion proofs against the ethereum node of the validator,
+// it's implemented by ethereum.EthereumRPCClient
+type ChainChecker interface {
+	CheckTxInclusionProof(ctx context.Context, proof *ethereum.TxInclusionProof) error
+}
+
+// ValidateTxInclusionProof verifies the proof and checks that it proves the requested transaction.
+// The proof is only checked to be consistent with the header it carries, see NewTxInclusionProofValidator.
+func ValidateTxInclusionProof(params string, payload []byte) (*ethereum.TxInclusionProof, error) {
+	proof, err := ethereum.DecodeTxInclusionProof(payload)
+	if err != nil {
+		return nil, err
+	}
+	if proof.TxHash != common.HexToHash(params) {
+		return nil, xerrors.Errorf("proof is for transaction %s, requested %s", proof.TxHash.Hex(), params)
+	}
+	return proof, nil
+}
+
+// NewTxInclusionProofValidator returns the validation of inclusion proofs which also checks
+// that the proven block is the canonical one by the ethereum node of the validator
+func NewTxInclusionProofValidator(chain ChainChecker) func(string, []byte) error {
+	return func(params string, payload []byte) error {
+		proof, err := ValidateTxInclusionProof(params, payload)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), chainCheckTimeout)
+		defer cancel()
+		if err := chain.CheckTxInclusionProof(ctx, proof); err != nil {
+			return xerrors.Errorf("proof doesn't match the chain: %w", err)
+		}
+		return nil
+	}
+}
+
+// rejectTxInclusionProof is used until the node registers the validator with its ethereum node,
+// inclusion proofs can't be trusted without checking them against the chain
+func rejectTxInclusionProof(string, []byte) error {
+	return xerrors.Errorf("no ethereum node to check inclusion proofs against")
+}
+
+func init() {
+	validation.RegisterValidation(rtypes.RPCTypeEthereum, map[string]func(string, []byte) error{
+		"getTransaction":      ValidateGetTransaction,
+		"getTokenBalance":     ValidateTokenAmount,
+		"getTokenTotalSupply": ValidateTokenAmount,
+		"getTxInclusionProof": rejectTxInclusionProof,
+	})
+}
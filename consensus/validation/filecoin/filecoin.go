@@ -2,6 +2,9 @@ package filecoin
 
 import (
 	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
 
 	"github.com/Secured-Finance/dione/consensus/validation"
 	rtypes "github.com/Secured-Finance/dione/rpc/types"
@@ -12,7 +15,7 @@ import (
 	"golang.org/x/xerrors"
 )
 
-func ValidateGetTransaction(payload []byte) error {
+func ValidateGetTransaction(_ string, payload []byte) error {
 	var msg ftypes.SignedMessage
 	if err := msg.UnmarshalCBOR(bytes.NewReader(payload)); err != nil {
 		if err := msg.Message.UnmarshalCBOR(bytes.NewReader(payload)); err != nil {
@@ -32,8 +35,56 @@ func ValidateGetTransaction(payload []byte) error {
 	}
 }
 
+// ValidateGetStorageDeal checks the deal status is consistent and is the one of requested deal.
+// Params format: "<deal id>:<epoch>", the status is read at the tipset of the epoch or of the last
+// epoch before it if the epoch is a null round.
+func ValidateGetStorageDeal(params string, payload []byte) error {
+	p := strings.Split(params, ":")
+	if len(p) != 2 {
+		return xerrors.Errorf("invalid params format, expected <deal id>:<epoch>")
+	}
+	dealID, err := strconv.ParseUint(p[0], 10, 64)
+	if err != nil {
+		return xerrors.Errorf("invalid deal id: %w", err)
+	}
+	epoch, err := strconv.ParseInt(p[1], 10, 64)
+	if err != nil {
+		return xerrors.Errorf("invalid epoch: %w", err)
+	}
+
+	var status ftypes.DealStatus
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&status); err != nil {
+		return xerrors.Errorf("cannot unmarshal payload: %w", err)
+	}
+	if status.DealID != dealID {
+		return xerrors.Errorf("status is of deal %d, requested %d", status.DealID, dealID)
+	}
+	if status.Epoch > epoch {
+		return xerrors.Errorf("status is read at epoch %d, after requested epoch %d", status.Epoch, epoch)
+	}
+	if status.Slashed != (status.SlashEpoch >= 0) {
+		return xerrors.Errorf("deal slashing status doesn't match slash epoch")
+	}
+	if status.Active != (status.ActivationEpoch >= 0 && !status.Slashed) {
+		return xerrors.Errorf("deal activation status doesn't match activation epoch")
+	}
+	return nil
+}
+
+func ValidateGetBlock(_ string, payload []byte) error {
+	result, err := validation.DecodeRPCResult(payload)
+	if err != nil {
+		return err
+	}
+	return validation.RequireJSONFields(result, "Miner", "Ticket", "Parents", "Height", "ParentStateRoot", "Messages", "Timestamp")
+}
+
 func init() {
-	validation.RegisterValidation(rtypes.RPCTypeFilecoin, map[string]func([]byte) error{
+	validation.RegisterValidation(rtypes.RPCTypeFilecoin, map[string]func(string, []byte) error{
 		"getTransaction": ValidateGetTransaction,
+		"getBlock":       ValidateGetBlock,
+		"getStorageDeal": ValidateGetStorageDeal,
 	})
 }
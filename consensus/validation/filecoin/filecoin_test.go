@@ -0,0 +1,47 @@
+package filecoin
+
+import (
+	"encoding/json"
+	"testing"
+
+	ftypes "github.com/Secured-Finance/dione/rpc/filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGetStorageDeal(t *testing.T) {
+	encode := func(status ftypes.DealStatus) []byte {
+		payload, err := json.Marshal(status)
+		require.NoError(t, err)
+		return payload
+	}
+	status := ftypes.DealStatus{
+		DealID:          42,
+		Epoch:           1000,
+		ActivationEpoch: 900,
+		SlashEpoch:      -1,
+		Active:          true,
+	}
+
+	assert.NoError(t, ValidateGetStorageDeal("42:1000", encode(status)))
+	// the epoch is a null round, so the status is read at the previous tipset
+	assert.NoError(t, ValidateGetStorageDeal("42:1001", encode(status)))
+
+	invalid := map[string]string{
+		"other deal":       "43:1000",
+		"later epoch":      "42:999",
+		"no epoch":         "42",
+		"invalid deal id":  "deal:1000",
+		"invalid epoch":    "42:epoch",
+		"too many params":  "42:1000:1",
+		"negative deal id": "-42:1000",
+		"empty params":     "",
+	}
+	for name, params := range invalid {
+		assert.Error(t, ValidateGetStorageDeal(params, encode(status)), name)
+	}
+
+	slashed := status
+	slashed.Slashed = true
+	assert.Error(t, ValidateGetStorageDeal("42:1000", encode(slashed)), "slashed deal without slash epoch")
+}
@@ -1,12 +1,22 @@
 package validation
 
-var validations = map[uint8]map[string]func([]byte) error{} // rpcType -> {rpcMethodName -> actual func var}
+var validations = map[uint8]map[string]func(string, []byte) error{} // rpcType -> {rpcMethodName -> actual func var}
 
-func RegisterValidation(typ uint8, methods map[string]func([]byte) error) {
+// RegisterValidation registers checks of payloads, which receive request params and the fetched payload
+func RegisterValidation(typ uint8, methods map[string]func(string, []byte) error) {
 	validations[typ] = methods
 }
 
-func GetValidationMethod(typ uint8, methodName string) func([]byte) error {
+// RegisterValidationMethod registers the check of single method, replacing the registered one.
+// It's used for checks which need clients set up by the node.
+func RegisterValidationMethod(typ uint8, methodName string, method func(string, []byte) error) {
+	if validations[typ] == nil {
+		validations[typ] = map[string]func(string, []byte) error{}
+	}
+	validations[typ][methodName] = method
+}
+
+func GetValidationMethod(typ uint8, methodName string) func(string, []byte) error {
 	rpcMethods, ok := validations[typ]
 	if !ok {
 		return nil
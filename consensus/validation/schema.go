@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+
+	rtypes "github.com/Secured-Finance/dione/rpc/types"
+	"golang.org/x/xerrors"
+)
+
+// DecodeRPCResult checks that payload is a complete JSON-RPC response without error and returns its result
+func DecodeRPCResult(payload []byte) (json.RawMessage, error) {
+	var response struct {
+		Jsonrpc string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Error   *rtypes.Error   `json:"error"`
+		ID      json.RawMessage `json:"id"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&response); err != nil {
+		return nil, xerrors.Errorf("malformed rpc response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, xerrors.Errorf("rpc response contains error %d: %s", response.Error.Code, response.Error.Message)
+	}
+	if len(response.Result) == 0 || string(response.Result) == "null" {
+		return nil, xerrors.Errorf("rpc response has empty result")
+	}
+	return response.Result, nil
+}
+
+// RequireJSONFields checks that obj is a JSON object containing all specified fields with non-null values
+func RequireJSONFields(obj []byte, fields ...string) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(obj, &m); err != nil {
+		return xerrors.Errorf("malformed json object: %w", err)
+	}
+	for _, f := range fields {
+		v, ok := m[f]
+		if !ok || string(v) == "null" {
+			return xerrors.Errorf("required field %s is missing", f)
+		}
+	}
+	return nil
+}
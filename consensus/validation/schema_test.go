@@ -0,0 +1,21 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRPCResult(t *testing.T) {
+	result, err := DecodeRPCResult([]byte(`{"jsonrpc":"2.0","result":{"Height":10,"Miner":"f01000"},"id":0}`))
+	assert.NoError(t, err)
+	assert.NoError(t, RequireJSONFields(result, "Height", "Miner"))
+	assert.Error(t, RequireJSONFields(result, "Height", "Parents"))
+
+	_, err = DecodeRPCResult([]byte(`{"jsonrpc":"2.0","error":{"code":1,"message":"not found"},"id":0}`))
+	assert.Error(t, err)
+	_, err = DecodeRPCResult([]byte(`{"jsonrpc":"2.0","result":null,"id":0}`))
+	assert.Error(t, err)
+	_, err = DecodeRPCResult([]byte(`{"jsonrpc":"2.0","result":{"Height":1`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package solana
+
+import (
+	"github.com/Secured-Finance/dione/consensus/validation"
+	rtypes "github.com/Secured-Finance/dione/rpc/types"
+)
+
+func ValidateGetTransaction(_ string, payload []byte) error {
+	result, err := validation.DecodeRPCResult(payload)
+	if err != nil {
+		return err
+	}
+	return validation.RequireJSONFields(result, "slot", "transaction", "meta")
+}
+
+func init() {
+	validation.RegisterValidation(rtypes.RPCTypeSolana, map[string]func(string, []byte) error{
+		"getTransaction": ValidateGetTransaction,
+	})
+}
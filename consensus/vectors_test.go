@@ -0,0 +1,119 @@
+package consensus
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	types2 "github.com/Secured-Finance/dione/consensus/types"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateVectors = flag.Bool("update", false, "rewrite test vectors in testdata")
+
+const vectorsPath = "testdata/vectors.json"
+
+// taskVector is the canonical encoding of signed consensus message, alternative implementations
+// must produce the same task hash, signature and wire encoding from the same seed and task
+type taskVector struct {
+	Seed        string          `json:"seed"` // ed25519 seed of the miner key
+	Task        types.DioneTask `json:"task"`
+	TaskHash    string          `json:"task_hash"` // signed as decimal string
+	Signature   string          `json:"signature"`
+	PrePrepare  string          `json:"pre_prepare"` // CBOR of the message published to pubsub
+	Description string          `json:"description"`
+}
+
+func newTaskVectors(t *testing.T) []*taskVector {
+	var vectors []*taskVector
+	for i, desc := range []string{"minimal task", "mined task with proofs and payload"} {
+		seed := make([]byte, ed25519.SeedSize)
+		for j := range seed {
+			seed[j] = byte(i + j)
+		}
+		privKey := ed25519.NewKeyFromSeed(seed)
+		pubKey, err := crypto.UnmarshalEd25519PublicKey(privKey.Public().(ed25519.PublicKey))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		miner, err := peer.IDFromPublicKey(pubKey)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		task := types.DioneTask{
+			Miner:       miner,
+			RequestID:   fmt.Sprint(i + 1),
+			ConsensusID: fmt.Sprint(i + 1),
+		}
+		if i == 1 {
+			task.OriginChain = 3
+			task.RequestType = "getRandomness"
+			task.RequestParams = "100:seed"
+			task.MinerEth = "0x0000000000000000000000000000000000000001"
+			task.Ticket = &types.Ticket{VRFProof: []byte{1, 2, 3}}
+			task.ElectionProof = &types.ElectionProof{WinCount: 2, VRFProof: []byte{4, 5, 6}}
+			task.BeaconEntries = []types.BeaconEntry{{Round: 99, Data: []byte{7}}, {Round: 100, Data: []byte{8}}}
+			task.DrandRound = 100
+			task.Payload = []byte{0xa1, 0x61, 0x72, 0x01}
+			task.FetchedAt = 1600000000
+			task.Deadline = 1600000300
+		}
+
+		hash, err := hashstructure.Hash(task, hashstructure.FormatV2, nil)
+		assert.NoError(t, err)
+		msg, err := CreatePrePrepareWithTaskSignature(&task, privKey)
+		assert.NoError(t, err)
+		data, err := cbor.Marshal(msg)
+		assert.NoError(t, err)
+
+		task.Signature = nil
+		vectors = append(vectors, &taskVector{
+			Seed:        hex.EncodeToString(seed),
+			Task:        task,
+			TaskHash:    fmt.Sprint(hash),
+			Signature:   hex.EncodeToString(msg.Payload.Task.Signature),
+			PrePrepare:  hex.EncodeToString(data),
+			Description: desc,
+		})
+	}
+	return vectors
+}
+
+func TestTaskVectors(t *testing.T) {
+	vectors := newTaskVectors(t)
+	if *updateVectors {
+		data, err := json.MarshalIndent(vectors, "", "  ")
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.FromSlash(vectorsPath), append(data, '\n'), 0644))
+		return
+	}
+
+	data, err := ioutil.ReadFile(filepath.FromSlash(vectorsPath))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var expected []*taskVector
+	assert.NoError(t, json.Unmarshal(data, &expected))
+	// encoding changes break compatibility with other nodes, update vectors only deliberately
+	assert.Equal(t, expected, vectors, "run go test ./consensus -run TestTaskVectors -update if encoding is changed deliberately")
+
+	for _, v := range expected {
+		raw, err := hex.DecodeString(v.PrePrepare)
+		assert.NoError(t, err)
+		var msg types2.Message
+		assert.NoError(t, cbor.Unmarshal(raw, &msg))
+		assert.Equal(t, types2.MessageTypePrePrepare, msg.Type)
+		assert.NoError(t, VerifyTaskSignature(msg.Payload.Task), v.Description)
+	}
+}
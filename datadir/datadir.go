@@ -0,0 +1,152 @@
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultDataDirName = ".dione"
+
+	keysDirName      = "keys"
+	storeDirName     = "store"
+	logsDirName      = "logs"
+	configName       = "config.toml"
+	addrBookName     = "peers.json"
+	deadLetterName   = "deadletter.json"
+	auditLogName     = "signing-audit.jsonl"
+	taskRegistryName = "tasks.json"
+	messagesDirName  = "messages"
+	banlistName      = "banlist.json"
+	metricsDirName   = "metrics"
+	lockFileName     = "LOCK"
+	dirPermission    = 0700
+)
+
+// DataDir owns the on-disk layout of the node: keys, databases, config and logs.
+// Only one node instance can hold a data directory at a time.
+type DataDir struct {
+	root     string
+	lockFile *os.File
+}
+
+// DefaultPath returns the data directory location used when none is configured
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return DefaultDataDirName
+	}
+	return filepath.Join(home, DefaultDataDirName)
+}
+
+// Open creates the directory structure on first run and takes an exclusive lock on it
+func Open(root string) (*DataDir, error) {
+	if root == "" {
+		root = DefaultPath()
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to resolve data directory path: %w", err)
+	}
+
+	dd := &DataDir{root: root}
+	for _, dir := range []string{dd.root, dd.KeysDir(), dd.StoreDir(), dd.LogsDir()} {
+		if err := os.MkdirAll(dir, dirPermission); err != nil {
+			return nil, xerrors.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	if err := dd.lock(); err != nil {
+		return nil, err
+	}
+
+	return dd, nil
+}
+
+func (dd *DataDir) lock() error {
+	f, err := os.OpenFile(filepath.Join(dd.root, lockFileName), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return xerrors.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return xerrors.Errorf("data directory %s is already in use by another node instance", dd.root)
+		}
+		return xerrors.Errorf("failed to lock data directory: %w", err)
+	}
+	dd.lockFile = f
+	return nil
+}
+
+// Close releases the lock on the data directory
+func (dd *DataDir) Close() error {
+	if dd.lockFile == nil {
+		return nil
+	}
+	if err := syscall.Flock(int(dd.lockFile.Fd()), syscall.LOCK_UN); err != nil {
+		return xerrors.Errorf("failed to unlock data directory: %w", err)
+	}
+	err := dd.lockFile.Close()
+	dd.lockFile = nil
+	return err
+}
+
+func (dd *DataDir) Root() string {
+	return dd.root
+}
+
+func (dd *DataDir) KeysDir() string {
+	return filepath.Join(dd.root, keysDirName)
+}
+
+// KeyPath returns the path of the key file with specified name
+func (dd *DataDir) KeyPath(name string) string {
+	return filepath.Join(dd.KeysDir(), name)
+}
+
+func (dd *DataDir) StoreDir() string {
+	return filepath.Join(dd.root, storeDirName)
+}
+
+// MessageStoreDir returns the directory of consensus messages kept for replay
+func (dd *DataDir) MessageStoreDir() string {
+	return filepath.Join(dd.StoreDir(), messagesDirName)
+}
+
+func (dd *DataDir) LogsDir() string {
+	return filepath.Join(dd.root, logsDirName)
+}
+
+// MetricsDir returns the directory of periodic metrics snapshots
+func (dd *DataDir) MetricsDir() string {
+	return filepath.Join(dd.LogsDir(), metricsDirName)
+}
+
+func (dd *DataDir) ConfigPath() string {
+	return filepath.Join(dd.root, configName)
+}
+
+func (dd *DataDir) AddressBookPath() string {
+	return filepath.Join(dd.root, addrBookName)
+}
+
+func (dd *DataDir) DeadLetterPath() string {
+	return filepath.Join(dd.root, deadLetterName)
+}
+
+func (dd *DataDir) BanlistPath() string {
+	return filepath.Join(dd.root, banlistName)
+}
+
+func (dd *DataDir) TaskRegistryPath() string {
+	return filepath.Join(dd.root, taskRegistryName)
+}
+
+// AuditLogPath returns the path of the hash-chained log of everything the node has signed
+func (dd *DataDir) AuditLogPath() string {
+	return filepath.Join(dd.LogsDir(), auditLogName)
+}
@@ -0,0 +1,155 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/lib"
+	"golang.org/x/xerrors"
+)
+
+// Stage is the step of the task lifecycle at which the task has failed
+type Stage string
+
+const (
+	StageMining     = Stage("mining")
+	StageSubmission = Stage("submission")
+)
+
+// Entry represents the task that has failed permanently
+type Entry struct {
+	RequestID     string    `json:"request_id"`
+	OriginChain   uint8     `json:"origin_chain"`
+	RequestType   string    `json:"request_type"`
+	RequestParams string    `json:"request_params"`
+	Payload       []byte    `json:"payload,omitempty"`
+	Stage         Stage     `json:"stage"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FailedAt      time.Time `json:"failed_at"`
+	TraceID       string    `json:"trace_id,omitempty"` // trace of the failed attempt, if tracing is enabled
+}
+
+// Queue keeps tasks which exhausted their retries and persists them on disk,
+// so the operator can inspect and requeue them later.
+type Queue struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]*Entry
+}
+
+func NewQueue(path string) (*Queue, error) {
+	q := &Queue{
+		path:    path,
+		entries: map[string]*Entry{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		return nil, xerrors.Errorf("failed to read dead-letter queue: %w", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, xerrors.Errorf("failed to decode dead-letter queue: %w", err)
+	}
+	for _, e := range entries {
+		q.entries[e.RequestID] = e
+	}
+
+	return q, nil
+}
+
+// Push adds the failed task to the queue, replacing the previous entry of the same request
+func (q *Queue) Push(e *Entry) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if e.FailedAt.IsZero() {
+		e.FailedAt = time.Now()
+	}
+	q.entries[e.RequestID] = e
+	return q.save()
+}
+
+// Get returns the entry of specified request or nil if there is no such entry
+func (q *Queue) Get(requestID string) *Entry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	e, ok := q.entries[requestID]
+	if !ok {
+		return nil
+	}
+	c := *e
+	return &c
+}
+
+// List returns all entries of the queue, the most recent failures go first
+func (q *Queue) List() []*Entry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.list()
+}
+
+// Remove deletes the entry of specified request from the queue
+func (q *Queue) Remove(requestID string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if _, ok := q.entries[requestID]; !ok {
+		return xerrors.Errorf("request %s is not in the dead-letter queue", requestID)
+	}
+	delete(q.entries, requestID)
+	return q.save()
+}
+
+// Prune removes entries failed before specified time and returns the count of removed entries
+func (q *Queue) Prune(before time.Time) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	pruned := 0
+	for id, e := range q.entries {
+		if e.FailedAt.Before(before) {
+			delete(q.entries, id)
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	return pruned, q.save()
+}
+
+func (q *Queue) list() []*Entry {
+	entries := make([]*Entry, 0, len(q.entries))
+	for _, e := range q.entries {
+		c := *e
+		entries = append(entries, &c)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FailedAt.After(entries[j].FailedAt)
+	})
+	return entries
+}
+
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(q.list(), "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to encode dead-letter queue: %w", err)
+	}
+
+	if err := lib.WriteFileAtomic(q.path, data, 0600); err != nil {
+		return xerrors.Errorf("failed to write dead-letter queue: %w", err)
+	}
+	return nil
+}
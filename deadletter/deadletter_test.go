@@ -0,0 +1,34 @@
+package deadletter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueuePersistence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deadletter.json")
+
+	q, err := NewQueue(path)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, q.Push(&Entry{RequestID: "1", Stage: StageMining, FailedAt: now.Add(-time.Minute)}))
+	assert.NoError(t, q.Push(&Entry{RequestID: "2", Stage: StageSubmission, Payload: []byte{1}, FailedAt: now}))
+
+	q, err = NewQueue(path)
+	assert.NoError(t, err)
+	entries := q.List()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "2", entries[0].RequestID)
+		assert.Equal(t, []byte{1}, entries[0].Payload)
+	}
+	assert.Equal(t, StageMining, q.Get("1").Stage)
+
+	assert.NoError(t, q.Remove("1"))
+	assert.Error(t, q.Remove("1"))
+	assert.Nil(t, q.Get("1"))
+}
@@ -0,0 +1,175 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/pprofhandler"
+	"golang.org/x/xerrors"
+)
+
+const (
+	pprofPath   = "/debug/pprof/"
+	runtimePath = "/debug/runtime"
+)
+
+// Profile kinds which can be collected from a running node
+const (
+	ProfileCPU       = "cpu"
+	ProfileHeap      = "heap"
+	ProfileGoroutine = "goroutine"
+	ProfileTrace     = "trace"
+)
+
+// RuntimeStats is the snapshot of Go runtime state of the node
+type RuntimeStats struct {
+	Uptime       string `json:"uptime"`
+	GoVersion    string `json:"go_version"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+}
+
+// Server exposes pprof profiles and runtime stats of the node. It's meant to be bound to loopback
+// or protected by token, since profiles reveal internals of the node.
+type Server struct {
+	listenAddr string
+	token      []byte
+	startedAt  time.Time
+}
+
+func NewServer(cfg *config.DiagnosticsConfig) *Server {
+	s := &Server{
+		listenAddr: cfg.ListenAddr,
+		token:      []byte(cfg.Token),
+		startedAt:  time.Now(),
+	}
+	if s.listenAddr == "" {
+		s.listenAddr = config.DefaultDiagnosticsListenAddr
+	}
+	return s
+}
+
+// Serve starts serving diagnostics requests, it blocks until ctx is done
+func (s *Server) Serve(ctx context.Context) error {
+	srv := &fasthttp.Server{Handler: s.handle}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(); err != nil {
+			logrus.Errorf("Failed to shutdown diagnostics server: %v", err)
+		}
+	}()
+	logrus.Infof("Diagnostics server is listening on %s", s.listenAddr)
+	return srv.ListenAndServe(s.listenAddr)
+}
+
+func (s *Server) handle(ctx *fasthttp.RequestCtx) {
+	if !s.authenticate(ctx) {
+		ctx.Error("unauthorized", fasthttp.StatusUnauthorized)
+		return
+	}
+	if !ctx.IsGet() {
+		ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+		return
+	}
+
+	path := string(ctx.Path())
+	switch {
+	case strings.HasPrefix(path, pprofPath):
+		pprofhandler.PprofHandler(ctx)
+	case path == runtimePath:
+		body, _ := json.Marshal(s.runtimeStats())
+		ctx.SetContentType("application/json")
+		ctx.SetBody(body)
+	default:
+		ctx.Error("not found", fasthttp.StatusNotFound)
+	}
+}
+
+func (s *Server) authenticate(ctx *fasthttp.RequestCtx) bool {
+	if len(s.token) == 0 {
+		return true
+	}
+	auth := string(ctx.Request.Header.Peek("Authorization"))
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if token == auth {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), s.token) == 1
+}
+
+func (s *Server) runtimeStats() *RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return &RuntimeStats{
+		Uptime:       time.Since(s.startedAt).Round(time.Second).String(),
+		GoVersion:    runtime.Version(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapInuse:    m.HeapInuse,
+		HeapObjects:  m.HeapObjects,
+		Sys:          m.Sys,
+		NumGC:        m.NumGC,
+		PauseTotalNs: m.PauseTotalNs,
+	}
+}
+
+// ProfilePath returns the request path collecting the profile of specified kind.
+// Duration applies to CPU profiles and traces only.
+func ProfilePath(kind string, duration time.Duration) (string, error) {
+	seconds := int(duration.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	switch kind {
+	case ProfileCPU:
+		return fmt.Sprintf("%sprofile?seconds=%d", pprofPath, seconds), nil
+	case ProfileTrace:
+		return fmt.Sprintf("%strace?seconds=%d", pprofPath, seconds), nil
+	case ProfileHeap:
+		return pprofPath + "heap", nil
+	case ProfileGoroutine:
+		return pprofPath + "goroutine?debug=2", nil
+	default:
+		return "", xerrors.Errorf("unknown profile kind %q", kind)
+	}
+}
+
+// FetchProfile collects the profile from the diagnostics server of a running node
+func FetchProfile(addr, token, kind string, duration time.Duration) ([]byte, error) {
+	path, err := ProfilePath(kind, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI("http://" + addr + path)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if err := fasthttp.DoTimeout(req, resp, duration+30*time.Second); err != nil {
+		return nil, xerrors.Errorf("failed to collect %s profile: %w", kind, err)
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, xerrors.Errorf("failed to collect %s profile: %d %s", kind, resp.StatusCode(), resp.Body())
+	}
+	return append([]byte(nil), resp.Body()...), nil
+}
@@ -0,0 +1,52 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestDiagnosticsServer(t *testing.T) {
+	s := NewServer(&config.DiagnosticsConfig{Token: "secret"})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	srv := &fasthttp.Server{Handler: s.handle}
+	go srv.Serve(ln)
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	_, err = FetchProfile(addr, "", ProfileHeap, 0)
+	assert.Error(t, err)
+
+	heap, err := FetchProfile(addr, "secret", ProfileHeap, 0)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, heap)
+
+	cpu, err := FetchProfile(addr, "secret", ProfileCPU, time.Second)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cpu)
+
+	goroutines, err := FetchProfile(addr, "secret", ProfileGoroutine, 0)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(goroutines), "goroutine"))
+
+	_, err = FetchProfile(addr, "secret", "mutexes", 0)
+	assert.Error(t, err)
+
+	var ctx fasthttp.RequestCtx
+	ctx.Request.SetRequestURI(runtimePath)
+	ctx.Request.Header.Set("Authorization", "Bearer secret")
+	s.handle(&ctx)
+	var stats RuntimeStats
+	assert.NoError(t, json.Unmarshal(ctx.Response.Body(), &stats))
+	assert.True(t, stats.Goroutines > 0)
+}
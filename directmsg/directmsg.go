@@ -0,0 +1,108 @@
+package directmsg
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	ProtocolID = protocol.ID("/dione/direct/1.0.0")
+
+	MaxMessageSize = 1 << 20
+	streamTimeout  = 30 * time.Second
+)
+
+// Message is a private message sent directly to the single peer
+type Message struct {
+	Topic string
+	Data  []byte
+	From  peer.ID `cbor:"-"`
+}
+
+type Handler func(message *Message)
+
+// Messenger sends messages to peers over dedicated libp2p streams instead of public gossip topics.
+// Streams are encrypted by the secure transport of libp2p connection with the keys of both peers,
+// so only the recipient is able to read the message.
+type Messenger struct {
+	host     host.Host
+	mutex    sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewMessenger(h host.Host) *Messenger {
+	m := &Messenger{
+		host:     h,
+		handlers: map[string]Handler{},
+	}
+	h.SetStreamHandler(ProtocolID, m.handleStream)
+	return m
+}
+
+// Hook sets the handler of messages with specified topic
+func (m *Messenger) Hook(topic string, handler Handler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.handlers[topic] = handler
+}
+
+// Send delivers the message to specified peer, connecting to it if needed
+func (m *Messenger) Send(ctx context.Context, to peer.ID, topic string, data []byte) error {
+	if len(data) > MaxMessageSize {
+		return xerrors.Errorf("message is too large: %d bytes", len(data))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, streamTimeout)
+	defer cancel()
+
+	s, err := m.host.NewStream(ctx, to, ProtocolID)
+	if err != nil {
+		return xerrors.Errorf("failed to open stream to %s: %w", to, err)
+	}
+	defer s.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetWriteDeadline(deadline)
+	}
+	if err := cbor.NewEncoder(s).Encode(&Message{Topic: topic, Data: data}); err != nil {
+		_ = s.Reset()
+		return xerrors.Errorf("failed to send message to %s: %w", to, err)
+	}
+
+	return nil
+}
+
+func (m *Messenger) handleStream(s network.Stream) {
+	defer s.Close()
+
+	from := s.Conn().RemotePeer()
+	_ = s.SetReadDeadline(time.Now().Add(streamTimeout))
+
+	var msg Message
+	// topic and CBOR framing take a few bytes on top of the data
+	if err := cbor.NewDecoder(io.LimitReader(s, MaxMessageSize+1024)).Decode(&msg); err != nil {
+		logrus.Warnf("Failed to decode direct message from %s: %v", from, err)
+		_ = s.Reset()
+		return
+	}
+	msg.From = from
+
+	m.mutex.RLock()
+	handler, ok := m.handlers[msg.Topic]
+	m.mutex.RUnlock()
+	if !ok {
+		logrus.Warnf("Dropping direct message with topic %s from %s because we don't have any handler!", msg.Topic, from)
+		return
+	}
+	handler(&msg)
+}
@@ -0,0 +1,36 @@
+package directmsg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessengerSend(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(context.Background(), 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	hosts := mn.Hosts()
+	sender := NewMessenger(hosts[0])
+	receiver := NewMessenger(hosts[1])
+
+	received := make(chan *Message, 1)
+	receiver.Hook("dkg_share", func(msg *Message) {
+		received <- msg
+	})
+
+	assert.NoError(t, sender.Send(context.Background(), hosts[1].ID(), "dkg_share", []byte("share")))
+	select {
+	case msg := <-received:
+		assert.Equal(t, []byte("share"), msg.Data)
+		assert.Equal(t, hosts[0].ID(), msg.From)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't received")
+	}
+
+	assert.Error(t, sender.Send(context.Background(), hosts[1].ID(), "dkg_share", make([]byte, MaxMessageSize+1)))
+}
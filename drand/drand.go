@@ -174,7 +174,7 @@ func (db *DrandBeacon) VerifyEntry(curr, prev types.BeaconEntry) error {
 func (db *DrandBeacon) LatestBeaconRound() uint64 {
 	latestDround, err := db.DrandClient.Get(context.TODO(), 0)
 	if err != nil {
-		log.Errorf("failed to get latest drand round: %w", err)
+		log.Errorf("failed to get latest drand round: %v", err)
 	}
 	return latestDround.Round()
 }
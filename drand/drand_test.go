@@ -7,6 +7,7 @@ import (
 
 	"github.com/Secured-Finance/dione/config"
 	drandClient "github.com/drand/drand/client/http"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,3 +22,17 @@ func TestPrintGroupInfo(t *testing.T) {
 	stringSha256 := hex.EncodeToString(drandResult.Randomness())
 	assert.Equal(t, stringSha256, "cb67e13477cad0e54540980a3b621dfd9c5fcd7c92ed42626289a1de6f25c3d1")
 }
+
+func TestVerifyRandomnessProofParams(t *testing.T) {
+	payload, err := cbor.Marshal(&RandomnessProof{Round: 100, Seed: []byte("seed")})
+	assert.NoError(t, err)
+
+	assert.Error(t, VerifyRandomnessProof("101:seed", payload))
+	assert.Error(t, VerifyRandomnessProof("100:other", payload))
+	assert.Error(t, VerifyRandomnessProof("100", payload))
+
+	// params match, so verification reaches the signature check
+	err = VerifyRandomnessProof("100:seed", payload)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid drand signature")
+}
@@ -0,0 +1,135 @@
+package drand
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/drand/drand/chain"
+	"github.com/drand/kyber"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/minio/blake2b-simd"
+	"golang.org/x/xerrors"
+)
+
+// RandomnessProof is a payload of randomness request.
+// Randomness is derived from drand round signature and request seed,
+// so anyone can verify it by checking the signature against drand group public key.
+type RandomnessProof struct {
+	Round             uint64
+	Signature         []byte
+	PreviousSignature []byte
+	Seed              []byte
+	Randomness        []byte
+}
+
+// GetRandomness returns CBOR-encoded RandomnessProof for specified drand round.
+// Params format: "<round>:<seed>", seed is an arbitrary string binding randomness to the request
+func (db *DrandBeacon) GetRandomness(params string) ([]byte, error) {
+	round, seed, err := parseRandomnessParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.TODO()
+	curr := <-db.Entry(ctx, round)
+	if curr.Err != nil {
+		return nil, curr.Err
+	}
+	prev := <-db.Entry(ctx, round-1)
+	if prev.Err != nil {
+		return nil, prev.Err
+	}
+
+	proof := &RandomnessProof{
+		Round:             round,
+		Signature:         curr.Entry.Data,
+		PreviousSignature: prev.Entry.Data,
+		Seed:              seed,
+	}
+	proof.Randomness = deriveRandomness(proof.Signature, proof.Seed)
+	if err := proof.verify(db.PublicKey); err != nil {
+		return nil, err
+	}
+
+	return cbor.Marshal(proof)
+}
+
+func (p *RandomnessProof) verify(publicKey kyber.Point) error {
+	err := chain.VerifyBeacon(publicKey, &chain.Beacon{
+		PreviousSig: p.PreviousSignature,
+		Round:       p.Round,
+		Signature:   p.Signature,
+	})
+	if err != nil {
+		return xerrors.Errorf("invalid drand signature for round %d: %w", p.Round, err)
+	}
+	if !bytes.Equal(p.Randomness, deriveRandomness(p.Signature, p.Seed)) {
+		return xerrors.Errorf("randomness doesn't match round signature and seed")
+	}
+	return nil
+}
+
+// VerifyRandomnessProof decodes CBOR-encoded RandomnessProof and verifies it against configured drand chain.
+// The proof must be made for round and seed of request params, otherwise the miner could choose
+// the randomness by picking the round.
+func VerifyRandomnessProof(params string, payload []byte) error {
+	round, seed, err := parseRandomnessParams(params)
+	if err != nil {
+		return err
+	}
+	var proof RandomnessProof
+	if err := cbor.Unmarshal(payload, &proof); err != nil {
+		return xerrors.Errorf("failed to decode randomness proof: %w", err)
+	}
+	if proof.Round != round || !bytes.Equal(proof.Seed, seed) {
+		return xerrors.Errorf("proof is made for round %d and seed %q, requested %s", proof.Round, proof.Seed, params)
+	}
+	publicKey, err := chainPublicKey()
+	if err != nil {
+		return err
+	}
+	return proof.verify(publicKey)
+}
+
+var (
+	chainInfoOnce sync.Once
+	chainInfo     *chain.Info
+	chainInfoErr  error
+)
+
+// chainPublicKey returns public key of the configured drand chain, chain info is parsed only once
+func chainPublicKey() (kyber.Point, error) {
+	chainInfoOnce.Do(func() {
+		chainInfo, chainInfoErr = chain.InfoFromJSON(strings.NewReader(config.NewDrandConfig().ChainInfo))
+		if chainInfoErr != nil {
+			chainInfoErr = xerrors.Errorf("unable to unmarshal drand chain info: %w", chainInfoErr)
+		}
+	})
+	if chainInfoErr != nil {
+		return nil, chainInfoErr
+	}
+	return chainInfo.PublicKey, nil
+}
+
+func parseRandomnessParams(params string) (uint64, []byte, error) {
+	p := strings.SplitN(params, ":", 2)
+	if len(p) != 2 {
+		return 0, nil, xerrors.Errorf("invalid params format, expected <round>:<seed>")
+	}
+	round, err := strconv.ParseUint(p[0], 10, 64)
+	if err != nil || round < 2 {
+		return 0, nil, xerrors.Errorf("invalid drand round: %s", p[0])
+	}
+	return round, []byte(p[1]), nil
+}
+
+func deriveRandomness(signature, seed []byte) []byte {
+	h := blake2b.New256()
+	h.Write(chain.RandomnessFromSignature(signature))
+	h.Write(seed)
+	return h.Sum(nil)
+}
@@ -65,62 +65,58 @@ func (c *EthereumClient) Initialize(cfg *config.EthereumConfig) error {
 	c.authTransactor = authTransactor
 	c.ethAddress = &c.authTransactor.From
 
-	stakingContract, err := dioneStaking.NewDioneStaking(common.HexToAddress(cfg.DioneStakingContractAddress), client)
+	return c.initContracts(cfg, bind.TransactOpts{
+		From:     authTransactor.From,
+		Signer:   authTransactor.Signer,
+		GasLimit: 0,   // 0 automatically estimates gas limit
+		GasPrice: nil, // nil automatically suggests gas price
+		Context:  context.Background(),
+	})
+}
+
+// InitializeReadOnly initializes the client without private key, so it can only read contracts and subscribe on events
+func (c *EthereumClient) InitializeReadOnly(cfg *config.EthereumConfig) error {
+	client, err := ethclient.Dial(cfg.GatewayAddress)
+	if err != nil {
+		return err
+	}
+	c.client = client
+
+	return c.initContracts(cfg, bind.TransactOpts{Context: context.Background()})
+}
+
+func (c *EthereumClient) initContracts(cfg *config.EthereumConfig, transactOpts bind.TransactOpts) error {
+	stakingContract, err := dioneStaking.NewDioneStaking(common.HexToAddress(cfg.DioneStakingContractAddress), c.client)
 	if err != nil {
 		return err
 	}
-	oracleContract, err := dioneOracle.NewDioneOracle(common.HexToAddress(cfg.DioneOracleContractAddress), client)
+	oracleContract, err := dioneOracle.NewDioneOracle(common.HexToAddress(cfg.DioneOracleContractAddress), c.client)
 	if err != nil {
 		return err
 	}
-	disputeContract, err := dioneDispute.NewDioneDispute(common.HexToAddress(cfg.DisputeContractAddress), client)
+	disputeContract, err := dioneDispute.NewDioneDispute(common.HexToAddress(cfg.DisputeContractAddress), c.client)
 	if err != nil {
 		return err
 	}
+	callOpts := bind.CallOpts{
+		Pending: true,
+		From:    transactOpts.From,
+		Context: context.Background(),
+	}
 	c.dioneStaking = &dioneStaking.DioneStakingSession{
-		Contract: stakingContract,
-		CallOpts: bind.CallOpts{
-			Pending: true,
-			From:    authTransactor.From,
-			Context: context.Background(),
-		},
-		TransactOpts: bind.TransactOpts{
-			From:     authTransactor.From,
-			Signer:   authTransactor.Signer,
-			GasLimit: 0,   // 0 automatically estimates gas limit
-			GasPrice: nil, // nil automatically suggests gas price
-			Context:  context.Background(),
-		},
+		Contract:     stakingContract,
+		CallOpts:     callOpts,
+		TransactOpts: transactOpts,
 	}
 	c.disputeContract = &dioneDispute.DioneDisputeSession{
-		Contract: disputeContract,
-		CallOpts: bind.CallOpts{
-			Pending: true,
-			From:    authTransactor.From,
-			Context: context.Background(),
-		},
-		TransactOpts: bind.TransactOpts{
-			From:     authTransactor.From,
-			Signer:   authTransactor.Signer,
-			GasLimit: 0,   // 0 automatically estimates gas limit
-			GasPrice: nil, // nil automatically suggests gas price
-			Context:  context.Background(),
-		},
+		Contract:     disputeContract,
+		CallOpts:     callOpts,
+		TransactOpts: transactOpts,
 	}
 	c.dioneOracle = &dioneOracle.DioneOracleSession{
-		Contract: oracleContract,
-		CallOpts: bind.CallOpts{
-			Pending: true,
-			From:    authTransactor.From,
-			Context: context.Background(),
-		},
-		TransactOpts: bind.TransactOpts{
-			From:     authTransactor.From,
-			Signer:   authTransactor.Signer,
-			GasLimit: 0,   // 0 automatically estimates gas limit
-			GasPrice: nil, // nil automatically suggests gas price
-			Context:  context.Background(),
-		},
+		Contract:     oracleContract,
+		CallOpts:     callOpts,
+		TransactOpts: transactOpts,
 	}
 	return nil
 }
@@ -131,6 +127,10 @@ func (c *EthereumClient) getPrivateKey(cfg *config.EthereumConfig) (*ecdsa.Priva
 		return key, err
 	}
 
+	if cfg.PrivateKeyFile != "" {
+		return crypto.LoadECDSA(cfg.PrivateKeyFile)
+	}
+
 	if cfg.MnemonicPhrase != "" {
 		wallet, err := hdwallet.NewFromMnemonic(cfg.MnemonicPhrase)
 		if err != nil {
@@ -157,7 +157,7 @@ func (c *EthereumClient) getPrivateKey(cfg *config.EthereumConfig) (*ecdsa.Priva
 		return key, nil
 	}
 
-	return nil, fmt.Errorf("private key or mnemonic phrase isn't specified")
+	return nil, fmt.Errorf("private key, private key file or mnemonic phrase isn't specified")
 }
 
 func (c *EthereumClient) GetEthAddress() *common.Address {
@@ -177,6 +177,67 @@ func (c *EthereumClient) SubscribeOnOracleEvents(ctx context.Context) (chan *dio
 	return resChan, subscription, err
 }
 
+// FilterOracleEvents returns oracle request events emitted in the specified block range (inclusive)
+func (c *EthereumClient) FilterOracleEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*dioneOracle.DioneOracleNewOracleRequest, error) {
+	it, err := c.dioneOracle.Contract.DioneOracleFilterer.FilterNewOracleRequest(&bind.FilterOpts{
+		Start:   fromBlock,
+		End:     &toBlock,
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var events []*dioneOracle.DioneOracleNewOracleRequest
+	for it.Next() {
+		events = append(events, it.Event)
+	}
+	return events, it.Error()
+}
+
+// FilterSettledOracleRequests returns IDs of oracle requests which were answered or cancelled
+// in the specified block range (inclusive)
+func (c *EthereumClient) FilterSettledOracleRequests(ctx context.Context, fromBlock, toBlock uint64) (map[string]struct{}, error) {
+	opts := &bind.FilterOpts{
+		Start:   fromBlock,
+		End:     &toBlock,
+		Context: ctx,
+	}
+	settled := map[string]struct{}{}
+
+	submitted, err := c.dioneOracle.Contract.DioneOracleFilterer.FilterSubmittedOracleRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+	for submitted.Next() {
+		settled[submitted.Event.ReqID.String()] = struct{}{}
+	}
+	submitted.Close()
+	if err := submitted.Error(); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := c.dioneOracle.Contract.DioneOracleFilterer.FilterCancelOracleRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+	for cancelled.Next() {
+		settled[cancelled.Event.ReqID.String()] = struct{}{}
+	}
+	cancelled.Close()
+	if err := cancelled.Error(); err != nil {
+		return nil, err
+	}
+
+	return settled, nil
+}
+
+// BlockNumber returns the number of the most recent block
+func (c *EthereumClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.client.BlockNumber(ctx)
+}
+
 func (c *EthereumClient) SubmitRequestAnswer(reqID *big.Int, data []byte) error {
 	_, err := c.dioneOracle.SubmitOracleRequest(reqID, data)
 	if err != nil {
@@ -67,7 +67,7 @@ func (c *EthereumClient) SendTransaction(ctx context.Context, private_key, to st
 
 	TxHash := signedTx.Hash().Hex()
 
-	logrus.Info("Transaction sent: %s", TxHash)
+	logrus.Infof("Transaction sent: %s", TxHash)
 
 	return TxHash
 }
@@ -0,0 +1,163 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/Secured-Finance/dione/datadir"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// ExportVersion is the version of the encrypted key export format
+	ExportVersion = 1
+
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltSize     = 32
+)
+
+// scryptN is the CPU/memory cost of deriving the encryption key from the passphrase
+var scryptN = 1 << 18
+
+type scryptParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+}
+
+// exportFile is the encrypted, versioned container of exported keys
+type exportFile struct {
+	Version    int          `json:"version"`
+	KDF        string       `json:"kdf"`
+	KDFParams  scryptParams `json:"kdf_params"`
+	Cipher     string       `json:"cipher"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+	Keys       []*KeyInfo   `json:"keys"` // public info of the exported keys, authenticated as additional data
+}
+
+// Export encrypts all keys of the data directory with the passphrase
+func Export(dataDir *datadir.DataDir, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, xerrors.Errorf("passphrase must not be empty")
+	}
+	keys, err := readKeys(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, xerrors.Errorf("there are no keys in %s", dataDir.KeysDir())
+	}
+
+	f := &exportFile{
+		Version:   ExportVersion,
+		KDF:       "scrypt",
+		KDFParams: scryptParams{N: scryptN, R: scryptR, P: scryptP, Salt: make([]byte, saltSize)},
+		Cipher:    "aes-256-gcm",
+	}
+	for name, data := range keys {
+		info, err := describe(name, data)
+		if err != nil {
+			return nil, err
+		}
+		f.Keys = append(f.Keys, info)
+	}
+	sort.Slice(f.Keys, func(i, j int) bool { return f.Keys[i].Name < f.Keys[j].Name })
+	if _, err := rand.Read(f.KDFParams.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := f.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := json.Marshal(f.Keys)
+	if err != nil {
+		return nil, err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return nil, err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, ad)
+
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// Import decrypts exported keys and stores them into the data directory.
+// Existing keys are never overwritten unless force is set.
+func Import(dataDir *datadir.DataDir, data []byte, passphrase string, force bool) ([]*KeyInfo, error) {
+	var f exportFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, xerrors.Errorf("malformed key export: %w", err)
+	}
+	if f.Version != ExportVersion {
+		return nil, xerrors.Errorf("unsupported key export version %d", f.Version)
+	}
+	if f.KDF != "scrypt" || f.Cipher != "aes-256-gcm" {
+		return nil, xerrors.Errorf("unsupported key export encryption %s/%s", f.KDF, f.Cipher)
+	}
+
+	aead, err := f.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, xerrors.Errorf("malformed key export nonce")
+	}
+	ad, err := json.Marshal(f.Keys)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, ad)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decrypt keys, wrong passphrase or corrupted export")
+	}
+	var keys map[string][]byte
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, xerrors.Errorf("malformed exported keys: %w", err)
+	}
+
+	var infos []*KeyInfo
+	for name, key := range keys {
+		info, err := describe(name, key)
+		if err != nil {
+			return nil, err
+		}
+		if !force {
+			if _, err := os.Stat(dataDir.KeyPath(storedName(name))); err == nil {
+				return nil, xerrors.Errorf("key %s already exists in %s", storedName(name), dataDir.KeysDir())
+			}
+		}
+		infos = append(infos, info)
+	}
+	if err := writeKeys(dataDir, keys); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func (f *exportFile) aead(passphrase string) (cipher.AEAD, error) {
+	p := f.KDFParams
+	key, err := scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, scryptKeyLen)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to derive encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
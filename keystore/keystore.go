@@ -0,0 +1,233 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/Secured-Finance/dione/datadir"
+	"github.com/Secured-Finance/dione/lib"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// IdentityKeyName is the ed25519 key of the node, it defines peer ID and signs the tasks
+	IdentityKeyName = "identity_privkey"
+	// BootstrapKeyName is the identity key of bootstrap nodes created before identity keys were persisted by all nodes
+	BootstrapKeyName = "bootstrap_privkey"
+	// EthereumKeyName is the secp256k1 key of the validator's ethereum account
+	EthereumKeyName = "ethereum_privkey"
+	// PreviousIdentityKeyName is the identity key replaced by the last rotation
+	PreviousIdentityKeyName = "identity_privkey.previous"
+
+	// LegacyBootstrapKeyPath is where bootstrap nodes kept their identity key in the working directory
+	// before the data directory was introduced
+	LegacyBootstrapKeyPath = ".bootstrap_privkey"
+
+	KeyTypeIdentity = "identity"
+	KeyTypeEthereum = "ethereum"
+)
+
+// KeyInfo describes the key stored in the data directory
+type KeyInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	ID   string `json:"id"` // peer ID of identity key or address of ethereum key
+}
+
+func keyType(name string) string {
+	switch name {
+	case IdentityKeyName, BootstrapKeyName:
+		return KeyTypeIdentity
+	case EthereumKeyName:
+		return KeyTypeEthereum
+	default:
+		return ""
+	}
+}
+
+// LoadIdentityKey returns the persisted identity key of the node or nil if there is no such key
+func LoadIdentityKey(dataDir *datadir.DataDir) (crypto.PrivKey, error) {
+	for _, name := range []string{IdentityKeyName, BootstrapKeyName} {
+		raw, err := ioutil.ReadFile(dataDir.KeyPath(name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read identity key: %w", err)
+		}
+		key, err := crypto.UnmarshalEd25519PrivateKey(raw)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode identity key %s: %w", name, err)
+		}
+		return key, nil
+	}
+	return nil, nil
+}
+
+// ImportLegacyBootstrapKey copies the bootstrap key kept at the legacy path into the data directory,
+// so bootstrap nodes keep their peer IDs after upgrade. Nothing is imported if the data directory
+// already has an identity key or there is no legacy key, it reports whether the key was imported.
+func ImportLegacyBootstrapKey(dataDir *datadir.DataDir, legacyPath string) (bool, error) {
+	key, err := LoadIdentityKey(dataDir)
+	if err != nil || key != nil {
+		return false, err
+	}
+	raw, err := ioutil.ReadFile(legacyPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("failed to read legacy bootstrap key: %w", err)
+	}
+	if _, err := crypto.UnmarshalEd25519PrivateKey(raw); err != nil {
+		return false, xerrors.Errorf("failed to decode legacy bootstrap key %s: %w", legacyPath, err)
+	}
+	if err := writeKey(dataDir.KeyPath(BootstrapKeyName), raw); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SaveIdentityKey persists the identity key of the node
+func SaveIdentityKey(dataDir *datadir.DataDir, key crypto.PrivKey) error {
+	raw, err := key.Raw()
+	if err != nil {
+		return err
+	}
+	return writeKey(dataDir.KeyPath(IdentityKeyName), raw)
+}
+
+// RotateIdentityKey replaces the identity key of the node with a new one, the node uses it after restart.
+// The replaced key is kept as PreviousIdentityKeyName, so the rotation can be reverted.
+func RotateIdentityKey(dataDir *datadir.DataDir) (crypto.PrivKey, error) {
+	previous, err := LoadIdentityKey(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to generate identity key: %w", err)
+	}
+	if previous != nil {
+		raw, err := previous.Raw()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeKey(dataDir.KeyPath(PreviousIdentityKeyName), raw); err != nil {
+			return nil, err
+		}
+	}
+	if err := SaveIdentityKey(dataDir, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// List returns info of all known keys stored in the data directory
+func List(dataDir *datadir.DataDir) ([]*KeyInfo, error) {
+	raw, err := readKeys(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var res []*KeyInfo
+	for name, data := range raw {
+		info, err := describe(name, data)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// readKeys returns raw private keys stored in the data directory keyed by their names
+func readKeys(dataDir *datadir.DataDir) (map[string][]byte, error) {
+	res := map[string][]byte{}
+	// only the key actually used as node identity is taken
+	for _, name := range []string{IdentityKeyName, BootstrapKeyName} {
+		data, err := ioutil.ReadFile(dataDir.KeyPath(name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read key %s: %w", name, err)
+		}
+		res[name] = data
+		break
+	}
+	ethKey, err := ethcrypto.LoadECDSA(dataDir.KeyPath(EthereumKeyName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("failed to read key %s: %w", EthereumKeyName, err)
+	}
+	if err == nil {
+		res[EthereumKeyName] = ethcrypto.FromECDSA(ethKey)
+	}
+	return res, nil
+}
+
+// storedName returns the name the imported key is stored under, identity keys are always stored as IdentityKeyName
+func storedName(name string) string {
+	if keyType(name) == KeyTypeIdentity {
+		return IdentityKeyName
+	}
+	return name
+}
+
+// writeKeys stores raw private keys into the data directory
+func writeKeys(dataDir *datadir.DataDir, keys map[string][]byte) error {
+	for name, data := range keys {
+		name = storedName(name)
+		if name == EthereumKeyName {
+			key, err := ethcrypto.ToECDSA(data)
+			if err != nil {
+				return xerrors.Errorf("invalid ethereum key: %w", err)
+			}
+			if err := ethcrypto.SaveECDSA(dataDir.KeyPath(name), key); err != nil {
+				return xerrors.Errorf("failed to save key %s: %w", name, err)
+			}
+			continue
+		}
+		if err := writeKey(dataDir.KeyPath(name), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func describe(name string, data []byte) (*KeyInfo, error) {
+	info := &KeyInfo{Name: name, Type: keyType(name)}
+	switch info.Type {
+	case KeyTypeIdentity:
+		key, err := crypto.UnmarshalEd25519PrivateKey(data)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode key %s: %w", name, err)
+		}
+		id, err := peer.IDFromPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		info.ID = id.String()
+	case KeyTypeEthereum:
+		key, err := ethcrypto.ToECDSA(data)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode key %s: %w", name, err)
+		}
+		info.ID = ethcrypto.PubkeyToAddress(key.PublicKey).Hex()
+	default:
+		return nil, xerrors.Errorf("unknown key %s", name)
+	}
+	return info, nil
+}
+
+func writeKey(path string, data []byte) error {
+	if err := lib.WriteFileAtomic(path, data, 0600); err != nil {
+		return xerrors.Errorf("failed to save key: %w", err)
+	}
+	return nil
+}
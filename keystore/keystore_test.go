@@ -0,0 +1,110 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Secured-Finance/dione/datadir"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func openDataDir(t *testing.T) (*datadir.DataDir, func()) {
+	dir := t.TempDir()
+	dd, err := datadir.Open(dir)
+	assert.NoError(t, err)
+	return dd, func() {
+		dd.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	scryptN = 1 << 10
+
+	src, closeSrc := openDataDir(t)
+	defer closeSrc()
+	identity, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, SaveIdentityKey(src, identity))
+	ethKey, err := ethcrypto.GenerateKey()
+	assert.NoError(t, err)
+	assert.NoError(t, ethcrypto.SaveECDSA(src.KeyPath(EthereumKeyName), ethKey))
+
+	keys, err := List(src)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	data, err := Export(src, "secret")
+	assert.NoError(t, err)
+
+	dst, closeDst := openDataDir(t)
+	defer closeDst()
+	_, err = Import(dst, data, "wrong", false)
+	assert.Error(t, err)
+
+	imported, err := Import(dst, data, "secret", false)
+	assert.NoError(t, err)
+	assert.Len(t, imported, 2)
+	dstKeys, err := List(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, keys, dstKeys)
+
+	// existing keys are kept
+	_, err = Import(dst, data, "secret", false)
+	assert.Error(t, err)
+}
+
+func TestImportLegacyBootstrapKey(t *testing.T) {
+	dd, closeDD := openDataDir(t)
+	defer closeDD()
+	legacyPath := filepath.Join(t.TempDir(), LegacyBootstrapKeyPath)
+
+	imported, err := ImportLegacyBootstrapKey(dd, legacyPath)
+	assert.NoError(t, err)
+	assert.False(t, imported)
+
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	raw, err := key.Raw()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(legacyPath, raw, 0600))
+
+	imported, err = ImportLegacyBootstrapKey(dd, legacyPath)
+	assert.NoError(t, err)
+	assert.True(t, imported)
+	loaded, err := LoadIdentityKey(dd)
+	assert.NoError(t, err)
+	assert.True(t, key.Equals(loaded))
+
+	// the key isn't imported again once the data directory has one
+	imported, err = ImportLegacyBootstrapKey(dd, legacyPath)
+	assert.NoError(t, err)
+	assert.False(t, imported)
+}
+
+func TestRotateIdentityKey(t *testing.T) {
+	dd, closeDD := openDataDir(t)
+	defer closeDD()
+
+	bootstrapKey, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	raw, err := bootstrapKey.Raw()
+	assert.NoError(t, err)
+	assert.NoError(t, writeKey(dd.KeyPath(BootstrapKeyName), raw))
+
+	key, err := RotateIdentityKey(dd)
+	assert.NoError(t, err)
+	assert.False(t, key.Equals(bootstrapKey))
+	loaded, err := LoadIdentityKey(dd)
+	assert.NoError(t, err)
+	assert.True(t, key.Equals(loaded))
+
+	// the replaced key is kept
+	previous, err := ioutil.ReadFile(dd.KeyPath(PreviousIdentityKeyName))
+	assert.NoError(t, err)
+	assert.Equal(t, raw, previous)
+}
@@ -0,0 +1,246 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultMaxSize    = 100 << 20
+	DefaultMaxBackups = 10
+
+	backupTimeFormat = "20060102T150405.000"
+	compressedExt    = ".gz"
+)
+
+// Options configures rotation of the log file
+type Options struct {
+	MaxSize        int64         // the file is rotated once it reaches the size
+	RotateInterval time.Duration // the file is rotated after the interval regardless of its size, zero disables it
+	MaxBackups     int           // count of rotated files kept
+	MaxAge         time.Duration // rotated files older than it are removed, zero keeps them until MaxBackups is exceeded
+	Compress       bool          // rotated files are gzipped
+}
+
+// Writer writes the log to a file and rotates it by size and age, so long-running nodes
+// don't depend on external logrotate. Rotated files are renamed to <name>-<time><ext>
+// and are compressed and pruned in background.
+type Writer struct {
+	path string
+	opts Options
+	now  func() time.Time
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	millMutex sync.Mutex
+	milling   sync.WaitGroup
+}
+
+// Open opens the log file for appending, zero options mean the default ones
+func Open(path string, opts Options) (*Writer, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	if opts.MaxBackups <= 0 {
+		opts.MaxBackups = DefaultMaxBackups
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, xerrors.Errorf("failed to create log directory: %w", err)
+	}
+	w := &Writer{path: path, opts: opts, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the log file, the file is rotated before the write if it would exceed the size limit
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.file == nil {
+		return 0, xerrors.Errorf("log file is closed")
+	}
+	tooOld := w.opts.RotateInterval > 0 && w.now().Sub(w.openedAt) >= w.opts.RotateInterval
+	if w.size > 0 && (w.size+int64(len(p)) > w.opts.MaxSize || tooOld) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new log file, e.g. on operator request
+func (w *Writer) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.rotate()
+}
+
+// Close closes the log file and waits until rotated files are compressed
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mutex.Unlock()
+	w.milling.Wait()
+	return err
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return xerrors.Errorf("failed to open log file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return xerrors.Errorf("failed to stat log file: %w", err)
+	}
+	w.file = f
+	w.size = fi.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return xerrors.Errorf("failed to close log file: %w", err)
+		}
+		w.file = nil
+	}
+	if err := os.Rename(w.path, w.backupPath(w.now())); err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("failed to rename log file: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.milling.Add(1)
+	go func() {
+		defer w.milling.Done()
+		w.mill()
+	}()
+	return nil
+}
+
+func (w *Writer) backupPath(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	return strings.TrimSuffix(w.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backup is the rotated log file
+type backup struct {
+	name string
+	time time.Time
+}
+
+// backups returns rotated files of the log, the most recent go first
+func (w *Writer) backups() ([]backup, error) {
+	dir := filepath.Dir(w.path)
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(filepath.Base(w.path), ext) + "-"
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list log directory: %w", err)
+	}
+	var backups []backup
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressedExt), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			// not a rotated file, e.g. log of other node sharing the directory
+			continue
+		}
+		backups = append(backups, backup{name: filepath.Join(dir, name), time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// mill compresses rotated files and removes ones over the limits. Failures can't be logged
+// since the writer is the output of the log itself, so they are written to stderr.
+func (w *Writer) mill() {
+	w.millMutex.Lock()
+	defer w.millMutex.Unlock()
+
+	backups, err := w.backups()
+	if err != nil {
+		reportError(err)
+		return
+	}
+	for i, b := range backups {
+		expired := w.opts.MaxAge > 0 && w.now().Sub(b.time) > w.opts.MaxAge
+		if i >= w.opts.MaxBackups || expired {
+			if err := os.Remove(b.name); err != nil && !os.IsNotExist(err) {
+				reportError(xerrors.Errorf("failed to remove rotated log file: %w", err))
+			}
+			continue
+		}
+		if w.opts.Compress && !strings.HasSuffix(b.name, compressedExt) {
+			if err := compress(b.name); err != nil {
+				reportError(err)
+			}
+		}
+	}
+}
+
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("failed to open rotated log file: %w", err)
+	}
+	defer src.Close()
+
+	tmp := path + compressedExt + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return xerrors.Errorf("failed to create compressed log file: %w", err)
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return xerrors.Errorf("failed to compress rotated log file: %w", err)
+	}
+	if err := os.Rename(tmp, path+compressedExt); err != nil {
+		return xerrors.Errorf("failed to compress rotated log file: %w", err)
+	}
+	return os.Remove(path)
+}
+
+func reportError(err error) {
+	os.Stderr.WriteString("logfile: " + err.Error() + "\n")
+}
@@ -0,0 +1,84 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "dione.log")
+	w, err := Open(path, Options{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	now := time.Now()
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := w.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(data))
+
+	backups, err := w.backups()
+	assert.NoError(t, err)
+	if !assert.Len(t, backups, 2) {
+		return
+	}
+	assert.True(t, strings.HasSuffix(backups[0].name, ".log"+compressedExt))
+	f, err := os.Open(backups[0].name)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err = ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, "third\n", string(data))
+}
+
+func TestWriterRotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "dione.log")
+	w, err := Open(path, Options{RotateInterval: time.Hour, MaxAge: 90 * time.Minute})
+	if !assert.NoError(t, err) {
+		return
+	}
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	start := now
+	for i := 0; i < 4; i++ {
+		_, err := w.Write([]byte("line\n"))
+		assert.NoError(t, err)
+		w.milling.Wait()
+		now = now.Add(time.Hour)
+	}
+	assert.NoError(t, w.Close())
+
+	// the file rotated first has expired by the last rotation
+	backups, err := w.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		assert.Equal(t, start.Add(2*time.Hour).Format(backupTimeFormat), backups[1].time.Format(backupTimeFormat))
+	}
+}
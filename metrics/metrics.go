@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultMaxFiles = 10
+
+	// maxFileSize is the size of snapshot file after which the next file of the ring is started
+	maxFileSize  = 1 << 20
+	filePrefix   = "metrics-"
+	fileExt      = ".jsonl"
+	fileIndexFmt = "%06d"
+)
+
+// LatencyStats summarizes durations observed since the previous snapshot
+type LatencyStats struct {
+	Count  int           `json:"count"`
+	Mean   time.Duration `json:"mean"`
+	Max    time.Duration `json:"max"`
+	Errors int           `json:"errors"`
+}
+
+// Snapshot is the state of key node metrics at some moment
+type Snapshot struct {
+	Time           time.Time    `json:"time"`
+	Peers          int          `json:"peers"`
+	Connectivity   string       `json:"connectivity"`
+	LastBlock      uint64       `json:"last_block"` // last ethereum block synced by the task registry
+	DeadLetters    int          `json:"dead_letters"`
+	ClockDrift     string       `json:"clock_drift,omitempty"`
+	Goroutines     int          `json:"goroutines"`
+	HeapAlloc      uint64       `json:"heap_alloc"`
+	RequestLatency LatencyStats `json:"request_latency"` // since the request event is received until the task is proposed
+}
+
+// Latency accumulates durations between snapshots
+type Latency struct {
+	mutex  sync.Mutex
+	count  int
+	total  time.Duration
+	max    time.Duration
+	errors int
+}
+
+// Observe records the duration of successful operation
+func (l *Latency) Observe(d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.count++
+	l.total += d
+	if d > l.max {
+		l.max = d
+	}
+}
+
+// ObserveError records the failed operation
+func (l *Latency) ObserveError() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.errors++
+}
+
+// Reset returns stats of recorded durations and starts accumulating from scratch
+func (l *Latency) Reset() LatencyStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	s := LatencyStats{Count: l.count, Max: l.max, Errors: l.errors}
+	if l.count > 0 {
+		s.Mean = l.total / time.Duration(l.count)
+	}
+	l.count, l.total, l.max, l.errors = 0, 0, 0, 0
+	return s
+}
+
+// Ring writes snapshots to a ring of JSON lines files, so history of metrics can be inspected
+// after an incident without external monitoring. The oldest file is removed once the count
+// of files exceeds the limit.
+type Ring struct {
+	dir      string
+	maxFiles int
+	mutex    sync.Mutex
+}
+
+func NewRing(dir string, maxFiles int) (*Ring, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, xerrors.Errorf("failed to create metrics directory: %w", err)
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxFiles
+	}
+	return &Ring{dir: dir, maxFiles: maxFiles}, nil
+}
+
+// Write appends the snapshot to the current file of the ring
+func (r *Ring) Write(s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return xerrors.Errorf("failed to encode metrics snapshot: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	files, err := r.files()
+	if err != nil {
+		return err
+	}
+	index := 0
+	if len(files) != 0 {
+		last := files[len(files)-1]
+		fmt.Sscanf(strings.TrimPrefix(last, filePrefix), fileIndexFmt, &index)
+		if fi, err := os.Stat(filepath.Join(r.dir, last)); err == nil && fi.Size()+int64(len(data)) > maxFileSize {
+			index++
+			files = append(files, r.fileName(index))
+		}
+	} else {
+		files = append(files, r.fileName(index))
+	}
+
+	f, err := os.OpenFile(filepath.Join(r.dir, r.fileName(index)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return xerrors.Errorf("failed to open metrics file: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	f.Close()
+	if err != nil {
+		return xerrors.Errorf("failed to write metrics file: %w", err)
+	}
+
+	for len(files) > r.maxFiles {
+		if err := os.Remove(filepath.Join(r.dir, files[0])); err != nil {
+			return xerrors.Errorf("failed to remove old metrics file: %w", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// Read returns all snapshots of the ring, the oldest go first
+func (r *Ring) Read() ([]*Snapshot, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	files, err := r.files()
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*Snapshot
+	for _, name := range files {
+		f, err := os.Open(filepath.Join(r.dir, name))
+		if err != nil {
+			return nil, xerrors.Errorf("failed to open metrics file: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), maxFileSize)
+		for scanner.Scan() {
+			var s Snapshot
+			if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+				f.Close()
+				return nil, xerrors.Errorf("failed to decode snapshot of %s: %w", name, err)
+			}
+			snapshots = append(snapshots, &s)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read metrics file: %w", err)
+		}
+	}
+	return snapshots, nil
+}
+
+// Run writes snapshots made by collect periodically, it blocks until ctx is done
+func (r *Ring) Run(ctx context.Context, interval time.Duration, collect func() *Snapshot) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s := collect()
+			s.Time = time.Now()
+			if err := r.Write(s); err != nil {
+				logrus.Errorf("Failed to write metrics snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// files returns names of the ring files ordered by their index
+func (r *Ring) files() ([]string, error) {
+	infos, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list metrics directory: %w", err)
+	}
+	var files []string
+	for _, fi := range infos {
+		if !fi.IsDir() && strings.HasPrefix(fi.Name(), filePrefix) && strings.HasSuffix(fi.Name(), fileExt) {
+			files = append(files, fi.Name())
+		}
+	}
+	// indexes are zero-padded, so lexical order is the order of files
+	sort.Strings(files)
+	return files, nil
+}
+
+func (r *Ring) fileName(index int) string {
+	return filePrefix + fmt.Sprintf(fileIndexFmt, index) + fileExt
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingRotatesFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	r, err := NewRing(dir, 2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// every snapshot takes a bit more than a third of the file, so a file holds two of them
+	big := &Snapshot{Connectivity: strings.Repeat("x", maxFileSize/3)}
+	for i := 0; i < 7; i++ {
+		big.Peers = i
+		assert.NoError(t, r.Write(big))
+	}
+
+	files, err := r.files()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"metrics-000002.jsonl", "metrics-000003.jsonl"}, files)
+
+	snapshots, err := r.Read()
+	assert.NoError(t, err)
+	if assert.Len(t, snapshots, 3) {
+		assert.Equal(t, 4, snapshots[0].Peers)
+		assert.Equal(t, 6, snapshots[2].Peers)
+	}
+}
+
+func TestLatencyReset(t *testing.T) {
+	var l Latency
+	l.Observe(time.Second)
+	l.Observe(3 * time.Second)
+	l.ObserveError()
+
+	assert.Equal(t, LatencyStats{Count: 2, Mean: 2 * time.Second, Max: 3 * time.Second, Errors: 1}, l.Reset())
+	assert.Equal(t, LatencyStats{}, l.Reset())
+}
@@ -0,0 +1,206 @@
+package msgstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/consensus/types"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultRetention = 72 * time.Hour
+
+	pruneInterval = time.Hour
+	// maxRoundSize bounds the disk space taken by a single consensus round,
+	// so peers can't exhaust it by flooding messages of one round
+	maxRoundSize = 16 << 20
+	// maxTotalSize bounds the disk space taken by all stored rounds
+	maxTotalSize = 1 << 30
+	roundFileExt = ".jsonl"
+)
+
+// Record is the consensus message as it was seen by the node
+type Record struct {
+	Time        time.Time         `json:"time"`
+	From        peer.ID           `json:"from"`
+	Outgoing    bool              `json:"outgoing"` // published by the node itself
+	Type        types.MessageType `json:"type"`
+	ConsensusID string            `json:"consensus_id"`
+	Data        []byte            `json:"data"` // raw message as it was received from or published to the network
+}
+
+// Store keeps consensus messages of recent rounds on disk, so disputes, slashing evidence
+// and post-mortems can replay exactly what the node saw. Every round is stored in its own
+// append-only file, rounds older than the retention are pruned.
+type Store struct {
+	dir       string
+	retention time.Duration
+	maxSize   int64
+	mutex     sync.Mutex
+	size      int64 // total size of stored rounds
+}
+
+// Open creates the store in specified directory, zero retention means the default one
+func Open(dir string, retention time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, xerrors.Errorf("failed to create message store directory: %w", err)
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	s := &Store{dir: dir, retention: retention, maxSize: maxTotalSize}
+
+	files, err := s.roundFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range files {
+		s.size += fi.Size()
+	}
+	return s, nil
+}
+
+// Append adds the message to the file of its consensus round
+func (s *Store) Append(r *Record) error {
+	if s == nil {
+		return nil
+	}
+	if r.ConsensusID == "" {
+		return xerrors.Errorf("message doesn't have consensus id")
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return xerrors.Errorf("failed to encode message: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data = append(data, '\n')
+	path := s.roundPath(r.ConsensusID)
+	if fi, err := os.Stat(path); err == nil && fi.Size()+int64(len(data)) > maxRoundSize {
+		return xerrors.Errorf("messages of consensus %s exceed %d bytes", r.ConsensusID, maxRoundSize)
+	}
+	if s.size+int64(len(data)) > s.maxSize {
+		return xerrors.Errorf("message store exceeds %d bytes", s.maxSize)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return xerrors.Errorf("failed to open message store: %w", err)
+	}
+	defer f.Close()
+	n, err := f.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return xerrors.Errorf("failed to write message store: %w", err)
+	}
+	return nil
+}
+
+// Messages returns the stored messages of consensus round in order they were seen
+func (s *Store) Messages(consensusID string) ([]*Record, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.Open(s.roundPath(consensusID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, xerrors.Errorf("failed to open message store: %w", err)
+	}
+	defer f.Close()
+
+	var records []*Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxRoundSize)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, xerrors.Errorf("failed to decode message %d of consensus %s: %w", len(records)+1, consensusID, err)
+		}
+		records = append(records, &r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read message store: %w", err)
+	}
+	return records, nil
+}
+
+// Prune removes rounds which haven't got messages since specified time and returns the count of removed rounds
+func (s *Store) Prune(before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	files, err := s.roundFiles()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, fi := range files {
+		if !fi.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, fi.Name())); err != nil {
+			return pruned, xerrors.Errorf("failed to remove %s: %w", fi.Name(), err)
+		}
+		s.size -= fi.Size()
+		pruned++
+	}
+	return pruned, nil
+}
+
+func (s *Store) roundFiles() ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to list message store: %w", err)
+	}
+	rounds := files[:0]
+	for _, fi := range files {
+		if !fi.IsDir() && strings.HasSuffix(fi.Name(), roundFileExt) {
+			rounds = append(rounds, fi)
+		}
+	}
+	return rounds, nil
+}
+
+// Run prunes rounds older than the retention periodically, it blocks until ctx is done
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if pruned, err := s.Prune(time.Now().Add(-s.retention)); err != nil {
+			logrus.Errorf("Failed to prune consensus messages: %v", err)
+		} else if pruned != 0 {
+			logrus.Debugf("Pruned consensus messages of %d rounds", pruned)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// roundPath returns the file of consensus round. Consensus IDs come from peers,
+// so they are hashed instead of being used in paths directly.
+func (s *Store) roundPath(consensusID string) string {
+	h := sha256.Sum256([]byte(consensusID))
+	return filepath.Join(s.dir, hex.EncodeToString(h[:16])+roundFileExt)
+}
@@ -0,0 +1,67 @@
+package msgstore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/consensus/types"
+	"github.com/libp2p/go-libp2p-core/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0)
+	assert.NoError(t, err)
+	peer1, err := test.RandPeerID()
+	assert.NoError(t, err)
+	peer2, err := test.RandPeerID()
+	assert.NoError(t, err)
+	assert.NoError(t, s.Append(&Record{From: peer1, Type: types.MessageTypePrePrepare, ConsensusID: "1", Data: []byte("pre_prepare")}))
+	assert.NoError(t, s.Append(&Record{From: peer2, Type: types.MessageTypePrepare, ConsensusID: "1", Data: []byte("prepare")}))
+	assert.NoError(t, s.Append(&Record{From: peer1, Outgoing: true, Type: types.MessageTypePrePrepare, ConsensusID: "../2", Data: []byte("other")}))
+	assert.Error(t, s.Append(&Record{Type: types.MessageTypeCommit, Data: []byte("commit")}))
+
+	records, err := s.Messages("1")
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, types.MessageTypePrePrepare, records[0].Type)
+		assert.Equal(t, []byte("pre_prepare"), records[0].Data)
+		assert.Equal(t, peer1, records[0].From)
+		assert.Equal(t, types.MessageTypePrepare, records[1].Type)
+	}
+	records, err = s.Messages("../2")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	pruned, err := s.Prune(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	records, err = s.Messages("1")
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestStoreTotalSize(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0)
+	assert.NoError(t, err)
+	// every record takes about 250 bytes
+	s.maxSize = 600
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, s.Append(&Record{Type: types.MessageTypePrepare, ConsensusID: strconv.Itoa(i), Data: make([]byte, 100)}))
+	}
+	assert.Error(t, s.Append(&Record{Type: types.MessageTypePrepare, ConsensusID: "2", Data: make([]byte, 100)}))
+
+	// the size of stored rounds is restored on reopening and freed by pruning
+	s, err = Open(dir, 0)
+	assert.NoError(t, err)
+	s.maxSize = 600
+	assert.Error(t, s.Append(&Record{Type: types.MessageTypePrepare, ConsensusID: "2", Data: make([]byte, 100)}))
+	_, err = s.Prune(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Append(&Record{Type: types.MessageTypePrepare, ConsensusID: "2", Data: make([]byte, 100)}))
+}
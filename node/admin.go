@@ -0,0 +1,173 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/Secured-Finance/dione/admin"
+	"github.com/Secured-Finance/dione/banlist"
+	"github.com/Secured-Finance/dione/deadletter"
+	"github.com/Secured-Finance/dione/keystore"
+	"github.com/Secured-Finance/dione/msgstore"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// adminBackend exposes node operations to the admin API
+type adminBackend struct {
+	n *Node
+}
+
+var _ admin.Backend = (*adminBackend)(nil)
+
+func (b *adminBackend) Status() *admin.Status {
+	s := &admin.Status{
+		PeerID:   b.n.Host.ID().String(),
+		Mode:     b.n.Config.Mode,
+		Peers:    len(b.n.Host.Network().Peers()),
+		LogLevel: logrus.GetLevel().String(),
+	}
+	if b.n.TaskRegistry != nil {
+		s.LastBlock = b.n.TaskRegistry.LastBlock()
+	}
+	if b.n.DeadLetters != nil {
+		s.DeadLetters = len(b.n.DeadLetters.List())
+	}
+	return s
+}
+
+func (b *adminBackend) ConnectPeer(ctx context.Context, addr string) (string, error) {
+	maddr, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		return "", xerrors.Errorf("invalid multiaddress: %w", err)
+	}
+	info, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		return "", xerrors.Errorf("multiaddress must contain peer ID: %w", err)
+	}
+	if err := b.n.Host.Connect(ctx, *info); err != nil {
+		b.n.AddressBook.MarkFailed(info.ID)
+		return "", xerrors.Errorf("failed to connect to peer: %w", err)
+	}
+	b.n.AddressBook.AddPeer(*info)
+	b.n.AddressBook.MarkConnected(info.ID)
+	return info.ID.String(), nil
+}
+
+func (b *adminBackend) DisconnectPeer(peerID string) error {
+	id, err := peer.Decode(peerID)
+	if err != nil {
+		return xerrors.Errorf("invalid peer ID: %w", err)
+	}
+	return b.n.Host.Network().ClosePeer(id)
+}
+
+func (b *adminBackend) Resync(ctx context.Context, fromBlock uint64) error {
+	if b.n.Ethereum == nil {
+		return xerrors.Errorf("seed node doesn't follow oracle requests")
+	}
+	head, err := b.n.Ethereum.BlockNumber(ctx)
+	if err != nil {
+		return xerrors.Errorf("failed to get latest ethereum block: %w", err)
+	}
+	if fromBlock > head {
+		return xerrors.Errorf("block %d is ahead of the chain head %d", fromBlock, head)
+	}
+	if head-fromBlock > MaxCatchUpBlocks {
+		return xerrors.Errorf("resync is limited by %d blocks", MaxCatchUpBlocks)
+	}
+	go func() {
+		logrus.Infof("Resyncing oracle requests from block %d to %d", fromBlock, head)
+		if err := b.n.syncOracleEvents(ctx, fromBlock, head); err != nil {
+			logrus.Errorf("Resync of oracle requests has failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (b *adminBackend) DeadLetters() ([]*deadletter.Entry, error) {
+	if b.n.DeadLetters == nil {
+		return nil, xerrors.Errorf("dead-letter queue is available on validators only")
+	}
+	return b.n.DeadLetters.List(), nil
+}
+
+func (b *adminBackend) RequeueDeadLetter(ctx context.Context, requestID string) error {
+	if b.n.DeadLetters == nil {
+		return xerrors.Errorf("dead-letter queue is available on validators only")
+	}
+	return b.n.RequeueDeadLetter(ctx, requestID)
+}
+
+func (b *adminBackend) PruneDeadLetters(olderThan time.Duration) (int, error) {
+	if b.n.DeadLetters == nil {
+		return 0, xerrors.Errorf("dead-letter queue is available on validators only")
+	}
+	return b.n.DeadLetters.Prune(time.Now().Add(-olderThan))
+}
+
+func (b *adminBackend) ConsensusMessages(consensusID string) ([]*msgstore.Record, error) {
+	if b.n.MessageStore == nil {
+		return nil, xerrors.Errorf("seed node doesn't store consensus messages")
+	}
+	return b.n.MessageStore.Messages(consensusID)
+}
+
+func (b *adminBackend) Banlist() *banlist.List {
+	return b.n.Banlist.Export()
+}
+
+func (b *adminBackend) BanPeer(peerID, reason string) error {
+	id, err := peer.Decode(peerID)
+	if err != nil {
+		return xerrors.Errorf("invalid peer ID: %w", err)
+	}
+	if err := b.n.Banlist.Ban(id, reason); err != nil {
+		return err
+	}
+	return b.n.Host.Network().ClosePeer(id)
+}
+
+func (b *adminBackend) UnbanPeer(peerID string) error {
+	id, err := peer.Decode(peerID)
+	if err != nil {
+		return xerrors.Errorf("invalid peer ID: %w", err)
+	}
+	return b.n.Banlist.Unban(id)
+}
+
+func (b *adminBackend) ImportBanlist(source string) (int, error) {
+	if source == "" {
+		return 0, xerrors.Errorf("source of banlist is required")
+	}
+	banned, err := b.n.Banlist.ImportFrom(source)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range banned {
+		if err := b.n.Host.Network().ClosePeer(id); err != nil {
+			logrus.Warnf("Failed to disconnect banned peer %s: %v", id, err)
+		}
+	}
+	return len(banned), nil
+}
+
+// RotateIdentityKey replaces the identity key in the data directory, the new peer ID is used after restart.
+// Bootstrap and seed nodes keep their keys, since other nodes dial them by peer ID.
+func (b *adminBackend) RotateIdentityKey() (string, error) {
+	if b.n.Config.IsBootstrap || b.n.Config.IsSeed() {
+		return "", xerrors.Errorf("identity key of bootstrap or seed node can't be rotated")
+	}
+	key, err := keystore.RotateIdentityKey(b.n.DataDir)
+	if err != nil {
+		return "", err
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	logrus.Warnf("Identity key has been rotated, the node will use peer ID %s after restart", id)
+	return id.String(), nil
+}
@@ -0,0 +1,126 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/Secured-Finance/dione/datadir"
+	"github.com/Secured-Finance/dione/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"golang.org/x/xerrors"
+)
+
+// NodeInfo is the public information about the node shared with network operators
+type NodeInfo struct {
+	PeerID     string `json:"peer_id"`
+	PublicKey  string `json:"public_key"` // hex-encoded ed25519 key signing the tasks
+	EthAddress string `json:"eth_address"`
+	Multiaddr  string `json:"multiaddr"`
+}
+
+var defaultConfigTemplate = template.Must(template.New("config").Parse(`# Generated by dione init, see docs/configuration.md for all options
+mode = "validator"
+data_dir = "{{.DataDir}}"
+bootstrap_node_multiaddr = []
+rendezvous = "filecoin-p2p-oracle"
+consensus_min_approvals = 2
+
+[listen]
+p2p = "0.0.0.0:{{.ListenPort}}"
+
+[ethereum]
+gateway_address = ""
+chain_id = 1
+private_key_file = "{{.EthereumKeyFile}}"
+oracle_contract_address = ""
+staking_contract_address = ""
+dispute_contract_address = ""
+
+[filecoin]
+lotusHost = ""
+lotusToken = ""
+
+[pubSub]
+protocolID = "p2p-oracle"
+serviceTopicName = "dione"
+`))
+
+// InitNode creates the data directory layout, generates node identity and ethereum keys
+// and writes default config. Existing keys and config are kept unless force is set.
+func InitNode(dataDirPath string, listenPort int, force bool) (*NodeInfo, error) {
+	dataDir, err := datadir.Open(dataDirPath)
+	if err != nil {
+		return nil, err
+	}
+	defer dataDir.Close()
+
+	identityKey, err := keystore.LoadIdentityKey(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if identityKey == nil || force {
+		identityKey, err = generatePrivateKey()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to generate identity key: %w", err)
+		}
+		if err := keystore.SaveIdentityKey(dataDir, identityKey); err != nil {
+			return nil, err
+		}
+	}
+
+	ethKeyPath := dataDir.KeyPath(keystore.EthereumKeyName)
+	if _, err := os.Stat(ethKeyPath); os.IsNotExist(err) || force {
+		ethKey, err := ethcrypto.GenerateKey()
+		if err != nil {
+			return nil, xerrors.Errorf("failed to generate ethereum key: %w", err)
+		}
+		if err := ethcrypto.SaveECDSA(ethKeyPath, ethKey); err != nil {
+			return nil, xerrors.Errorf("failed to save ethereum key: %w", err)
+		}
+	}
+	ethKey, err := ethcrypto.LoadECDSA(ethKeyPath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load ethereum key: %w", err)
+	}
+
+	if _, err := os.Stat(dataDir.ConfigPath()); os.IsNotExist(err) || force {
+		if err := writeDefaultConfig(dataDir, listenPort, ethKeyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	peerID, err := peer.IDFromPrivateKey(identityKey)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := identityKey.GetPublic().Raw()
+	if err != nil {
+		return nil, err
+	}
+	return &NodeInfo{
+		PeerID:     peerID.String(),
+		PublicKey:  fmt.Sprintf("%x", pubKey),
+		EthAddress: ethcrypto.PubkeyToAddress(ethKey.PublicKey).Hex(),
+		Multiaddr:  fmt.Sprintf("/ip4/0.0.0.0/tcp/%d/p2p/%s", listenPort, peerID),
+	}, nil
+}
+
+func writeDefaultConfig(dataDir *datadir.DataDir, listenPort int, ethKeyPath string) error {
+	f, err := os.OpenFile(dataDir.ConfigPath(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return xerrors.Errorf("failed to create config: %w", err)
+	}
+	defer f.Close()
+
+	err = defaultConfigTemplate.Execute(f, map[string]interface{}{
+		"ListenPort":      listenPort,
+		"DataDir":         dataDir.Root(),
+		"EthereumKeyFile": ethKeyPath,
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
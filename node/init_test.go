@@ -0,0 +1,34 @@
+package node
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/Secured-Finance/dione/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitNode(t *testing.T) {
+	dir := t.TempDir()
+
+	info, err := InitNode(dir, 8100, false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, info.PeerID)
+	assert.NotEmpty(t, info.EthAddress)
+
+	cfg, err := config.Load(filepath.Join(dir, "config.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, 8100, cfg.ListenPort)
+	assert.Equal(t, filepath.Join(dir, "keys", keystore.EthereumKeyName), cfg.Ethereum.PrivateKeyFile)
+
+	// existing keys are kept
+	again, err := InitNode(dir, 8100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, info, again)
+
+	regenerated, err := InitNode(dir, 8100, true)
+	assert.NoError(t, err)
+	assert.NotEqual(t, info.PeerID, regenerated.PeerID)
+	assert.NotEqual(t, info.EthAddress, regenerated.EthAddress)
+}
@@ -5,19 +5,46 @@ import (
 	"crypto/rand"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math/big"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
 	"time"
 
 	pex "github.com/Secured-Finance/go-libp2p-pex"
 
+	"github.com/Secured-Finance/dione/addrbook"
+	"github.com/Secured-Finance/dione/admin"
+	"github.com/Secured-Finance/dione/alerting"
+	"github.com/Secured-Finance/dione/audit"
+	"github.com/Secured-Finance/dione/banlist"
 	"github.com/Secured-Finance/dione/cache"
+	"github.com/Secured-Finance/dione/clockdrift"
+	"github.com/Secured-Finance/dione/connectivity"
 	"github.com/Secured-Finance/dione/consensus"
+	"github.com/Secured-Finance/dione/consensus/validation"
+	ethvalidation "github.com/Secured-Finance/dione/consensus/validation/ethereum"
+	"github.com/Secured-Finance/dione/datadir"
+	"github.com/Secured-Finance/dione/deadletter"
+	"github.com/Secured-Finance/dione/diagnostics"
+	"github.com/Secured-Finance/dione/directmsg"
+	"github.com/Secured-Finance/dione/keystore"
+	"github.com/Secured-Finance/dione/logfile"
+	"github.com/Secured-Finance/dione/metrics"
+	"github.com/Secured-Finance/dione/msgstore"
+	"github.com/Secured-Finance/dione/reorg"
+	"github.com/Secured-Finance/dione/taskregistry"
+	"github.com/Secured-Finance/dione/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 
 	"github.com/Secured-Finance/dione/drand"
 
+	"github.com/Secured-Finance/dione/contracts/dioneOracle"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/libp2p/go-libp2p-core/peer"
 
@@ -28,8 +55,13 @@ import (
 
 	solana2 "github.com/Secured-Finance/dione/rpc/solana"
 
+	"github.com/Secured-Finance/dione/rpc/ethereum"
 	"github.com/Secured-Finance/dione/rpc/filecoin"
 
+	_ "github.com/Secured-Finance/dione/consensus/validation/drand"    // enable payload validation of randomness tasks
+	_ "github.com/Secured-Finance/dione/consensus/validation/filecoin" // enable payload validation of filecoin tasks
+	_ "github.com/Secured-Finance/dione/consensus/validation/solana"   // enable payload validation of solana tasks
+
 	"github.com/Secured-Finance/dione/types"
 
 	"github.com/Secured-Finance/dione/wallet"
@@ -42,6 +74,7 @@ import (
 	"github.com/Secured-Finance/dione/ethclient"
 	pubsub2 "github.com/Secured-Finance/dione/pubsub"
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/connmgr"
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/multiformats/go-multiaddr"
@@ -49,16 +82,37 @@ import (
 )
 
 const (
-	DefaultPEXUpdateTime = 6 * time.Second
+	DefaultPEXUpdateTime         = 6 * time.Second
+	DefaultAddressBookSavePeriod = 1 * time.Minute
+
+	// MaxTaskAttempts is the count of attempts to mine the task before the request goes to dead-letter queue
+	MaxTaskAttempts = 3
+	taskRetryDelay  = 5 * time.Second
+
+	// MaxCatchUpBlocks limits how far back the node looks for oracle requests emitted while it was offline
+	MaxCatchUpBlocks   = 5000
+	catchUpBatchBlocks = 1000
+
+	DefaultShutdownTimeout = 30 * time.Second
 )
 
 type Node struct {
 	Host             host.Host
+	Banlist          *banlist.Banlist
+	BanlistFeeds     *banlist.Feeds
 	PeerDiscovery    discovery.Discovery
+	AddressBook      *addrbook.AddressBook
+	Connectivity     *connectivity.Maintainer
 	PubSubRouter     *pubsub2.PubSubRouter
+	MessageStore     *msgstore.Store
+	DirectMessenger  *directmsg.Messenger
+	ClockDrift       *clockdrift.Monitor
+	Metrics          *metrics.Ring
+	RequestLatency   metrics.Latency
 	GlobalCtx        context.Context
 	GlobalCtxCancel  context.CancelFunc
 	Config           *config.Config
+	DataDir          *datadir.DataDir
 	Ethereum         *ethclient.EthereumClient
 	ConsensusManager *consensus.PBFTConsensusManager
 	Miner            *consensus.Miner
@@ -66,21 +120,109 @@ type Node struct {
 	Wallet           *wallet.LocalWallet
 	EventCache       cache.EventCache
 	DisputeManager   *consensus.DisputeManager
+	DeadLetters      *deadletter.Queue
+	TaskRegistry     *taskregistry.Registry
+	Alerter          *alerting.Alerter
+	AuditLog         *audit.Log
+	EthereumRPC      *ethereum.EthereumRPCClient
+	ReorgMonitor     *reorg.Monitor
+	Lotus            *filecoin.LotusClient
+	LotusProxy       *filecoin.LotusProxy
+	Admin            *admin.Server
+	Diagnostics      *diagnostics.Server
 }
 
-func NewNode(config *config.Config, prvKey crypto.PrivKey, pexDiscoveryUpdateTime time.Duration) (*Node, error) {
+func NewNode(config *config.Config, dataDir *datadir.DataDir, prvKey crypto.PrivKey, pexDiscoveryUpdateTime time.Duration) (*Node, error) {
 	n := &Node{
-		Config: config,
+		Config:  config,
+		DataDir: dataDir,
 	}
 
+	// initialize banlist of peers, it gates connections of libp2p host
+	bans, err := provideBanlist(n.Config, n.DataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.Banlist = bans
+	logrus.Info("Banlist has loaded!")
+
 	// initialize libp2p host
-	lhost, err := provideLibp2pHost(n.Config, prvKey, pexDiscoveryUpdateTime)
+	lhost, err := provideLibp2pHost(n.Config, prvKey, bans, pexDiscoveryUpdateTime)
 	if err != nil {
 		logrus.Fatal(err)
 	}
 	n.Host = lhost
 	logrus.Info("Started up Libp2p host!")
 
+	// initialize subscription to shared banlist feeds
+	n.BanlistFeeds = provideBanlistFeeds(n.Config, bans, lhost)
+	logrus.Info("Banlist feeds have initialized!")
+
+	// initialize store of consensus messages, seed nodes don't take part in consensus
+	if !n.Config.IsSeed() {
+		messageStore, err := provideMessageStore(n.Config, n.DataDir)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		n.MessageStore = messageStore
+		logrus.Info("Consensus message store has initialized!")
+	}
+
+	// initialize pubsub subsystem
+	psb := providePubsubRouter(lhost, n.Config, n.MessageStore)
+	n.PubSubRouter = psb
+	logrus.Info("PubSub subsystem has initialized!")
+
+	// initialize peer discovery
+	peerDiscovery, err := providePeerDiscovery(n.Config, lhost, pexDiscoveryUpdateTime)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.PeerDiscovery = peerDiscovery
+	logrus.Info("Peer discovery subsystem has initialized!")
+
+	// initialize address book of known peers
+	addressBook, err := provideAddressBook(n.DataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.AddressBook = addressBook
+	logrus.Info("Address book has loaded!")
+
+	// initialize alerting on critical conditions
+	alerter := provideAlerter(n.Config)
+	n.Alerter = alerter
+	logrus.Info("Alerting subsystem has initialized!")
+
+	// initialize connectivity maintainer
+	connMaintainer, err := provideConnectivityMaintainer(n.Config, lhost, addressBook, alerter)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.Connectivity = connMaintainer
+	logrus.Info("Connectivity maintainer has initialized!")
+
+	// initialize admin api
+	if n.Config.Admin.Enabled {
+		adminServer, err := provideAdminServer(n)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		n.Admin = adminServer
+		logrus.Info("Admin API has initialized!")
+	}
+
+	// initialize diagnostics server
+	if n.Config.Diagnostics.Enabled {
+		n.Diagnostics = provideDiagnosticsServer(config)
+		logrus.Info("Diagnostics server has initialized!")
+	}
+
+	if n.Config.IsSeed() {
+		logrus.Info("Node is running in seed mode, only peer discovery is enabled")
+		return n, nil
+	}
+
 	// initialize ethereum client
 	ethClient, err := provideEthereumClient(n.Config)
 	if err != nil {
@@ -96,18 +238,32 @@ func NewNode(config *config.Config, prvKey crypto.PrivKey, pexDiscoveryUpdateTim
 	}
 	logrus.Info("RPC clients has successfully configured!")
 
-	// initialize pubsub subsystem
-	psb := providePubsubRouter(lhost, n.Config)
-	n.PubSubRouter = psb
-	logrus.Info("PubSub subsystem has initialized!")
+	// initialize lotus proxy
+	if n.Config.Filecoin.Proxy.Enabled {
+		lotusProxy, err := filecoin.NewLotusProxy(n.Lotus, &n.Config.Filecoin.Proxy)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		n.LotusProxy = lotusProxy
+		logrus.Info("Lotus proxy has initialized!")
+	}
 
-	// initialize peer discovery
-	peerDiscovery, err := providePeerDiscovery(n.Config, lhost, pexDiscoveryUpdateTime)
+	// initialize direct messaging between validators
+	n.DirectMessenger = provideDirectMessenger(lhost)
+	logrus.Info("Direct messaging subsystem has initialized!")
+
+	// initialize clock drift monitor
+	clockDrift, err := provideClockDriftMonitor(n.Config, lhost, n.DirectMessenger, alerter)
 	if err != nil {
 		logrus.Fatal(err)
 	}
-	n.PeerDiscovery = peerDiscovery
-	logrus.Info("Peer discovery subsystem has initialized!")
+	n.ClockDrift = clockDrift
+	logrus.Info("Clock drift monitor has initialized!")
+
+	// initialize reorg monitor of the source chain
+	reorgMonitor := provideReorgMonitor(n.EthereumRPC, alerter)
+	n.ReorgMonitor = reorgMonitor
+	logrus.Info("Reorg monitor has initialized!")
 
 	// get private key of libp2p host
 	rawPrivKey, err := prvKey.Raw()
@@ -123,18 +279,66 @@ func NewNode(config *config.Config, prvKey crypto.PrivKey, pexDiscoveryUpdateTim
 	n.Beacon = randomBeaconNetwork
 	logrus.Info("Random beacon subsystem has initialized!")
 
-	// initialize mining subsystem
-	miner := provideMiner(n.Host.ID(), *n.Ethereum.GetEthAddress(), n.Beacon, n.Ethereum, rawPrivKey)
-	n.Miner = miner
-	logrus.Info("Mining subsystem has initialized!")
+	// serve randomness requests from the random beacon
+	err = n.setupRandomnessRPC()
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
 	// initialize event log cache subsystem
 	eventCache := provideEventCache(config)
 	n.EventCache = eventCache
 	logrus.Info("Event cache subsystem has initialized!")
 
+	// initialize registry of received oracle requests
+	taskRegistry, err := provideTaskRegistry(n.DataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.TaskRegistry = taskRegistry
+	logrus.Info("Task registry has loaded!")
+
+	// initialize ring of metrics snapshots
+	if n.Config.MetricsSnapshots.Interval > 0 {
+		metricsRing, err := provideMetricsRing(n.Config, n.DataDir)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		n.Metrics = metricsRing
+		logrus.Info("Metrics snapshots have initialized!")
+	}
+
+	if n.Config.IsFollower() {
+		logrus.Info("Node is running in follower mode, consensus subsystems are disabled")
+		return n, nil
+	}
+
+	// initialize mining subsystem
+	miner, err := provideMiner(n.Config, n.Host.ID(), *n.Ethereum.GetEthAddress(), n.Beacon, n.Ethereum, rawPrivKey)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.Miner = miner
+	logrus.Info("Mining subsystem has initialized!")
+
+	// initialize dead-letter queue of failed tasks
+	deadLetters, err := provideDeadLetterQueue(n.DataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.DeadLetters = deadLetters
+	logrus.Info("Dead-letter queue has loaded!")
+
+	// initialize audit log of signed payloads
+	auditLog, err := provideAuditLog(n.DataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	n.AuditLog = auditLog
+	logrus.Info("Audit log has opened!")
+
 	// initialize consensus subsystem
-	cManager := provideConsensusManager(psb, miner, ethClient, rawPrivKey, n.Config.ConsensusMinApprovals, eventCache)
+	cManager := provideConsensusManager(psb, miner, ethClient, rawPrivKey, n.Config.ConsensusMinApprovals, eventCache, n.Config.FaultInjection, deadLetters, n.Alerter, auditLog, reorgMonitor)
 	n.ConsensusManager = cManager
 	logrus.Info("Consensus subsystem has initialized!")
 
@@ -157,17 +361,109 @@ func NewNode(config *config.Config, prvKey crypto.PrivKey, pexDiscoveryUpdateTim
 }
 
 func (n *Node) Run(ctx context.Context) error {
+	n.logListeners()
 	n.runLibp2pAsync(ctx)
-	n.subscribeOnEthContractsAsync(ctx)
+	go n.BanlistFeeds.Run(ctx)
+	if !n.Config.IsSeed() {
+		n.runDataSourcesAsync(ctx)
+		go n.ClockDrift.Run(ctx)
+		go n.MessageStore.Run(ctx)
+	}
+	if n.ConsensusManager != nil {
+		go n.ConsensusManager.Run(ctx)
+	}
+	if n.Metrics != nil {
+		go n.Metrics.Run(ctx, time.Duration(n.Config.MetricsSnapshots.Interval)*time.Second, n.collectMetrics)
+	}
+	if n.Admin != nil {
+		go func() {
+			if err := n.Admin.Serve(ctx); err != nil {
+				logrus.Errorf("Admin API has stopped: %v", err)
+			}
+		}()
+	}
+	if n.Diagnostics != nil {
+		go func() {
+			if err := n.Diagnostics.Serve(ctx); err != nil {
+				logrus.Errorf("Diagnostics server has stopped: %v", err)
+			}
+		}()
+	}
+
+	addrBookSaveTicker := time.NewTicker(DefaultAddressBookSavePeriod)
+	defer addrBookSaveTicker.Stop()
 
 	for {
 		select {
+		case <-addrBookSaveTicker.C:
+			if err := n.AddressBook.Save(); err != nil {
+				logrus.Errorf("Failed to save address book: %v", err)
+			}
 		case <-ctx.Done():
 			return nil
 		}
 	}
+}
 
-	// return nil
+// logListeners prints the summary of addresses the node servers are bound to
+func (n *Node) logListeners() {
+	logrus.Info("Node is listening on:")
+	for _, l := range n.Config.Listeners() {
+		if l.Name != "libp2p" {
+			logrus.Infof("  %s: %s", l.Name, l.Addr)
+			continue
+		}
+		for _, addr := range n.Host.Addrs() {
+			logrus.Infof("  %s: %s/p2p/%s", l.Name, addr, n.Host.ID())
+		}
+	}
+}
+
+// Shutdown stops the node subsystems in dependency order: gossip isn't received anymore,
+// in-flight consensus rounds are finished or abstained from, stores are flushed and
+// libp2p host is closed last. Stores are flushed even if ctx is done before consensus is finished.
+func (n *Node) Shutdown(ctx context.Context) error {
+	var result error
+
+	n.PubSubRouter.Shutdown()
+	if n.ConsensusManager != nil {
+		if err := n.ConsensusManager.Shutdown(ctx); err != nil {
+			logrus.Warn(err)
+			result = err
+		}
+	}
+
+	if err := n.AddressBook.Save(); err != nil {
+		logrus.Errorf("Failed to save address book: %v", err)
+		result = err
+	}
+	if err := n.AuditLog.Close(); err != nil {
+		logrus.Errorf("Failed to close audit log: %v", err)
+		result = err
+	}
+
+	if err := n.Host.Close(); err != nil {
+		logrus.Errorf("Failed to close libp2p host: %v", err)
+		result = err
+	}
+	return result
+}
+
+func (n *Node) runDataSourcesAsync(ctx context.Context) {
+	n.subscribeOnEthContractsAsync(ctx)
+	lotusHealthCheckInterval := time.Duration(n.Config.Filecoin.HealthCheckInterval) * time.Second
+	if lotusHealthCheckInterval <= 0 {
+		lotusHealthCheckInterval = filecoin.DefaultHealthCheckInterval
+	}
+	go n.Lotus.RunHealthChecks(ctx, lotusHealthCheckInterval)
+	go n.ReorgMonitor.Run(ctx)
+	if n.LotusProxy != nil {
+		go func() {
+			if err := n.LotusProxy.Serve(ctx); err != nil {
+				logrus.Errorf("Lotus proxy has stopped: %v", err)
+			}
+		}()
+	}
 }
 
 func (n *Node) runLibp2pAsync(ctx context.Context) error {
@@ -180,6 +476,9 @@ func (n *Node) runLibp2pAsync(ctx context.Context) error {
 	}
 	logrus.Info("Successfully announced!")
 
+	go n.connectToKnownPeers(ctx)
+	go n.Connectivity.Run(ctx)
+
 	// Discover unbounded count of peers
 	logrus.Info("Searching for other peers...")
 	peerChan, err := n.PeerDiscovery.FindPeers(context.TODO(), n.Config.Rendezvous)
@@ -201,10 +500,14 @@ func (n *Node) runLibp2pAsync(ctx context.Context) error {
 						continue
 					}
 					logrus.Infof("Found peer: %s", newPeer)
+					n.AddressBook.AddPeer(newPeer)
 					// Connect to the peer
 					if err := n.Host.Connect(ctx, newPeer); err != nil {
 						logrus.Warn("Connection failed: ", err)
+						n.AddressBook.MarkFailed(newPeer.ID)
+						continue
 					}
+					n.AddressBook.MarkConnected(newPeer.ID)
 					logrus.Info("Connected to newly discovered peer: ", newPeer)
 				}
 			}
@@ -213,38 +516,39 @@ func (n *Node) runLibp2pAsync(ctx context.Context) error {
 	return nil
 }
 
+// connectToKnownPeers dials peers stored in the address book from previous runs
+func (n *Node) connectToKnownPeers(ctx context.Context) {
+	for _, p := range n.AddressBook.Peers() {
+		if p.ID == n.Host.ID() {
+			continue
+		}
+		if err := n.Host.Connect(ctx, p); err != nil {
+			logrus.Debugf("Failed to connect to known peer %s: %v", p.ID, err)
+			n.AddressBook.MarkFailed(p.ID)
+			continue
+		}
+		n.AddressBook.MarkConnected(p.ID)
+		logrus.Info("Connected to known peer: ", p.ID)
+	}
+}
+
 func (n *Node) subscribeOnEthContractsAsync(ctx context.Context) {
+	// the last synced block is taken before subscribing, so blocks of live events can't hide the offline gap
+	lastBlock := n.TaskRegistry.LastBlock()
 	eventChan, subscription, err := n.Ethereum.SubscribeOnOracleEvents(ctx)
 	if err != nil {
 		logrus.Fatal("Couldn't subscribe on ethereum contracts, exiting... ", err)
 	}
 
+	go n.catchUpOracleEvents(ctx, lastBlock)
+
 	go func() {
 	EventLoop:
 		for {
 			select {
 			case event := <-eventChan:
 				{
-					err := n.EventCache.Store("request_"+event.ReqID.String(), event)
-					if err != nil {
-						logrus.Errorf("Failed to store new request event to event log cache: %v", err)
-					}
-
-					logrus.Info("Let's wait a little so that all nodes have time to receive the request and cache it")
-					time.Sleep(5 * time.Second)
-
-					task, err := n.Miner.MineTask(context.TODO(), event)
-					if err != nil {
-						logrus.Errorf("Failed to mine task: %v", err)
-					}
-					if task == nil {
-						continue
-					}
-					logrus.Infof("Proposed new Dione task with ID: %s", event.ReqID.String())
-					err = n.ConsensusManager.Propose(*task)
-					if err != nil {
-						logrus.Errorf("Failed to propose task: %w", err)
-					}
+					n.handleOracleEvent(ctx, event)
 				}
 			case <-ctx.Done():
 				break EventLoop
@@ -255,6 +559,324 @@ func (n *Node) subscribeOnEthContractsAsync(ctx context.Context) {
 	}()
 }
 
+// handleOracleEvent registers the oracle request, so it's never processed twice, and starts processing it
+func (n *Node) handleOracleEvent(ctx context.Context, event *dioneOracle.DioneOracleNewOracleRequest) {
+	isNew, err := n.TaskRegistry.Add(event.ReqID.String(), event.Raw.BlockNumber)
+	if err != nil {
+		logrus.Errorf("Failed to save request %s to task registry: %v", event.ReqID.String(), err)
+	}
+	if !isNew {
+		logrus.Debugf("Request %s is already registered, skipping...", event.ReqID.String())
+		return
+	}
+
+	err = n.EventCache.Store("request_"+event.ReqID.String(), event)
+	if err != nil {
+		logrus.Errorf("Failed to store new request event to event log cache: %v", err)
+	}
+
+	if n.Config.IsFollower() {
+		return
+	}
+
+	// requests are processed concurrently, so waits and retries of one request don't hold up the others
+	go func() {
+		logrus.Info("Let's wait a little so that all nodes have time to receive the request and cache it")
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return
+		}
+		n.processOracleRequest(ctx, event)
+	}()
+}
+
+// catchUpOracleEvents processes oracle requests emitted since the last synced block while the node was offline.
+// On the first run there is nothing to catch up, so the current head is just marked as synced.
+func (n *Node) catchUpOracleEvents(ctx context.Context, lastBlock uint64) {
+	head, err := n.Ethereum.BlockNumber(ctx)
+	if err != nil {
+		logrus.Errorf("Failed to get latest ethereum block, skipping catch-up of oracle requests: %v", err)
+		return
+	}
+	if lastBlock == 0 || lastBlock >= head {
+		if err := n.TaskRegistry.SetLastBlock(head); err != nil {
+			logrus.Errorf("Failed to save task registry: %v", err)
+		}
+		return
+	}
+
+	from := lastBlock + 1
+	if head-from > MaxCatchUpBlocks {
+		logrus.Warnf("Node is behind by %d blocks, catching up oracle requests of the last %d blocks only", head-from, MaxCatchUpBlocks)
+		from = head - MaxCatchUpBlocks
+	}
+	logrus.Infof("Catching up oracle requests from block %d to %d", from, head)
+
+	if err := n.syncOracleEvents(ctx, from, head); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// syncOracleEvents handles oracle requests emitted in specified blocks. Already registered requests are skipped,
+// as well as requests which were answered or cancelled on-chain while the node was offline.
+func (n *Node) syncOracleEvents(ctx context.Context, from, to uint64) error {
+	settled := map[string]struct{}{}
+	for start := from; start <= to; start += catchUpBatchBlocks {
+		end := start + catchUpBatchBlocks - 1
+		if end > to {
+			end = to
+		}
+		ids, err := n.Ethereum.FilterSettledOracleRequests(ctx, start, end)
+		if err != nil {
+			return xerrors.Errorf("failed to get settled oracle requests of blocks %d-%d: %w", start, end, err)
+		}
+		for id := range ids {
+			settled[id] = struct{}{}
+		}
+	}
+
+	for start := from; start <= to; start += catchUpBatchBlocks {
+		end := start + catchUpBatchBlocks - 1
+		if end > to {
+			end = to
+		}
+		events, err := n.Ethereum.FilterOracleEvents(ctx, start, end)
+		if err != nil {
+			return xerrors.Errorf("failed to get oracle requests of blocks %d-%d: %w", start, end, err)
+		}
+		for _, event := range events {
+			if _, ok := settled[event.ReqID.String()]; ok {
+				logrus.Debugf("Request %s is already answered or cancelled, skipping...", event.ReqID.String())
+				if _, err := n.TaskRegistry.Add(event.ReqID.String(), event.Raw.BlockNumber); err != nil {
+					logrus.Errorf("Failed to save request %s to task registry: %v", event.ReqID.String(), err)
+				}
+				continue
+			}
+			n.handleOracleEvent(ctx, event)
+		}
+		if err := n.TaskRegistry.SetLastBlock(end); err != nil {
+			logrus.Errorf("Failed to save task registry: %v", err)
+		}
+	}
+	return nil
+}
+
+// processOracleRequest draws the leader election of the oracle request and proposes the task if the node wins it.
+// The request goes to the dead-letter queue if the election can't be drawn or the answer couldn't be fetched
+// after MaxTaskAttempts.
+func (n *Node) processOracleRequest(ctx context.Context, event *dioneOracle.DioneOracleNewOracleRequest) {
+	ctx = tracing.WithRequestID(ctx, event.ReqID.String())
+	ctx, span := tracing.StartSpan(ctx, "oracle_request", attribute.String("request_id", event.ReqID.String()))
+	defer span.End()
+	log := tracing.Logger(ctx)
+	start := time.Now()
+
+	// nothing is fetched or tracked for the task which won't be proposed anyway
+	if n.ClockDrift.RefusesProposals() {
+		drift, _ := n.ClockDrift.Drift()
+		log.Warnf("Request %s isn't mined because local clock drift %s is over the tolerance", event.ReqID.String(), drift.Round(time.Millisecond))
+		return
+	}
+
+	election, err := n.Miner.Elect(ctx)
+	if err != nil {
+		log.Errorf("Failed to draw election of request %s, moving it to dead-letter queue: %v", event.ReqID.String(), err)
+		tracing.RecordError(span, err)
+		n.RequestLatency.ObserveError()
+		n.deadLetterRequest(ctx, event, err, 1)
+		return
+	}
+	if election == nil {
+		return
+	}
+
+	dataSource := fmt.Sprintf("%d/%s", event.OriginChain, event.RequestType)
+	var task *types.DioneTask
+	var anchor *reorg.Anchor
+	for attempt := 1; attempt <= MaxTaskAttempts; attempt++ {
+		task, anchor, err = n.Miner.MineTask(ctx, election, event)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		log.Warnf("Failed to mine task (attempt %d of %d): %v", attempt, MaxTaskAttempts, err)
+		if attempt < MaxTaskAttempts {
+			select {
+			case <-time.After(taskRetryDelay):
+			case <-ctx.Done():
+			}
+		}
+	}
+	if err != nil {
+		log.Errorf("Failed to mine task of request %s, moving it to dead-letter queue: %v", event.ReqID.String(), err)
+		tracing.RecordError(span, err)
+		n.RequestLatency.ObserveError()
+		// the node is shutting down, so the failure says nothing about the data source
+		if ctx.Err() == nil {
+			n.Alerter.ReportFailure(alerting.AlertDataSourceDown, dataSource, fmt.Sprintf("failed to fetch %s for request %s: %v", dataSource, event.ReqID.String(), err))
+		}
+		n.deadLetterRequest(ctx, event, err, MaxTaskAttempts)
+		return
+	}
+	n.Alerter.ReportSuccess(alerting.AlertDataSourceDown, dataSource)
+	if anchor != nil {
+		n.ReorgMonitor.Track(event.ReqID.String(), *anchor)
+	}
+	log.Infof("Proposed new Dione task with ID: %s", event.ReqID.String())
+	err = n.ConsensusManager.Propose(ctx, *task)
+	if err != nil {
+		log.Errorf("Failed to propose task: %v", err)
+		n.RequestLatency.ObserveError()
+		return
+	}
+	n.RequestLatency.Observe(time.Since(start))
+}
+
+// collectMetrics makes the snapshot of key node metrics
+func (n *Node) collectMetrics() *metrics.Snapshot {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s := &metrics.Snapshot{
+		Peers:          len(n.Host.Network().Peers()),
+		Connectivity:   n.Connectivity.Status().String(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAlloc:      mem.HeapAlloc,
+		RequestLatency: n.RequestLatency.Reset(),
+	}
+	if n.TaskRegistry != nil {
+		s.LastBlock = n.TaskRegistry.LastBlock()
+	}
+	if n.DeadLetters != nil {
+		s.DeadLetters = len(n.DeadLetters.List())
+	}
+	if n.ClockDrift != nil {
+		if drift, known := n.ClockDrift.Drift(); known {
+			s.ClockDrift = drift.Round(time.Millisecond).String()
+		}
+	}
+	return s
+}
+
+// deadLetterRequest moves the request which task couldn't be mined to the dead-letter queue
+func (n *Node) deadLetterRequest(ctx context.Context, event *dioneOracle.DioneOracleNewOracleRequest, err error, attempts int) {
+	dlErr := n.DeadLetters.Push(&deadletter.Entry{
+		RequestID:     event.ReqID.String(),
+		OriginChain:   event.OriginChain,
+		RequestType:   event.RequestType,
+		RequestParams: event.RequestParams,
+		Stage:         deadletter.StageMining,
+		Error:         err.Error(),
+		Attempts:      attempts,
+		TraceID:       tracing.TraceID(ctx),
+	})
+	if dlErr != nil {
+		tracing.Logger(ctx).Errorf("Failed to add request %s to dead-letter queue: %v", event.ReqID.String(), dlErr)
+	}
+}
+
+// RequeueDeadLetter removes the request from the dead-letter queue and processes it again.
+// Requests failed at submission are resubmitted with already agreed payload.
+func (n *Node) RequeueDeadLetter(ctx context.Context, requestID string) error {
+	if n.Config.IsFollower() {
+		return xerrors.Errorf("follower node doesn't process requests")
+	}
+	entry := n.DeadLetters.Get(requestID)
+	if entry == nil {
+		return xerrors.Errorf("request %s is not in the dead-letter queue", requestID)
+	}
+
+	switch entry.Stage {
+	case deadletter.StageSubmission:
+		reqID, ok := new(big.Int).SetString(entry.RequestID, 10)
+		if !ok {
+			return xerrors.Errorf("failed to parse request ID: %s", entry.RequestID)
+		}
+		if err := n.Ethereum.SubmitRequestAnswer(reqID, entry.Payload); err != nil {
+			return xerrors.Errorf("failed to submit on-chain result: %w", err)
+		}
+		return n.DeadLetters.Remove(requestID)
+	case deadletter.StageMining:
+		event, err := n.EventCache.GetOracleRequestEvent("request_" + requestID)
+		if err != nil || event == nil {
+			return xerrors.Errorf("request %s is missing in event cache", requestID)
+		}
+		if err := n.DeadLetters.Remove(requestID); err != nil {
+			return err
+		}
+		go n.processOracleRequest(ctx, event)
+		return nil
+	default:
+		return xerrors.Errorf("unknown stage of dead-letter entry: %s", entry.Stage)
+	}
+}
+
+func provideAuditLog(dataDir *datadir.DataDir) (*audit.Log, error) {
+	l, err := audit.Open(dataDir.AuditLogPath())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open audit log: %w", err)
+	}
+	return l, nil
+}
+
+func provideTaskRegistry(dataDir *datadir.DataDir) (*taskregistry.Registry, error) {
+	r, err := taskregistry.NewRegistry(dataDir.TaskRegistryPath())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load task registry: %w", err)
+	}
+	return r, nil
+}
+
+func provideDeadLetterQueue(dataDir *datadir.DataDir) (*deadletter.Queue, error) {
+	q, err := deadletter.NewQueue(dataDir.DeadLetterPath())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load dead-letter queue: %w", err)
+	}
+	return q, nil
+}
+
+func provideAddressBook(dataDir *datadir.DataDir) (*addrbook.AddressBook, error) {
+	ab, err := addrbook.NewAddressBook(dataDir.AddressBookPath())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load address book: %w", err)
+	}
+	return ab, nil
+}
+
+func provideAlerter(config *config.Config) *alerting.Alerter {
+	return alerting.NewAlerter(&config.Alerting)
+}
+
+func provideAdminServer(n *Node) (*admin.Server, error) {
+	return admin.NewServer(&adminBackend{n: n}, &n.Config.Admin)
+}
+
+func provideDiagnosticsServer(config *config.Config) *diagnostics.Server {
+	return diagnostics.NewServer(&config.Diagnostics)
+}
+
+func provideReorgMonitor(ethRPC *ethereum.EthereumRPCClient, alerter *alerting.Alerter) *reorg.Monitor {
+	return reorg.NewMonitor(ethRPC.BlockHash, reorg.DefaultCheckInterval, alerter)
+}
+
+func provideConnectivityMaintainer(config *config.Config, h host.Host, ab *addrbook.AddressBook, alerter *alerting.Alerter) (*connectivity.Maintainer, error) {
+	var bootstrapPeers []peer.AddrInfo
+	if !config.IsBootstrap {
+		for _, a := range config.BootstrapNodes {
+			maddr, err := multiaddr.NewMultiaddr(a)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid multiaddress of bootstrap node: %v", err)
+			}
+			info, err := peer.AddrInfoFromP2pAddr(maddr)
+			if err != nil {
+				logrus.Warnf("Bootstrap node address %s doesn't contain peer ID, it won't be used for reconnection", a)
+				continue
+			}
+			bootstrapPeers = append(bootstrapPeers, *info)
+		}
+	}
+	return connectivity.NewMaintainer(h, ab, bootstrapPeers, config.MinPeers, connectivity.DefaultCheckInterval, alerter), nil
+}
+
 func provideEventCache(config *config.Config) cache.EventCache {
 	var backend cache.EventCache
 	switch config.CacheType {
@@ -272,8 +894,12 @@ func provideDisputeManager(ctx context.Context, ethClient *ethclient.EthereumCli
 	return consensus.NewDisputeManager(ctx, ethClient, pcm, cfg.Ethereum.DisputeVoteWindow)
 }
 
-func provideMiner(peerID peer.ID, ethAddress common.Address, beacon beacon.BeaconNetworks, ethClient *ethclient.EthereumClient, privateKey []byte) *consensus.Miner {
-	return consensus.NewMiner(peerID, ethAddress, beacon, ethClient, privateKey)
+func provideMiner(config *config.Config, peerID peer.ID, ethAddress common.Address, beacon beacon.BeaconNetworks, ethClient *ethclient.EthereumClient, privateKey []byte) (*consensus.Miner, error) {
+	fetches, err := rpc.NewScheduler(config.FetchLimits)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to setup fetch limits: %w", err)
+	}
+	return consensus.NewMiner(peerID, ethAddress, beacon, ethClient, privateKey, consensus.NewStalenessPolicy(config.TaskDeadline, config.MaxAnswerAge), fetches), nil
 }
 
 func provideBeacon(ps *pubsub.PubSub) (beacon.BeaconNetworks, error) {
@@ -306,7 +932,12 @@ func provideWallet(peerID peer.ID, privKey []byte) (*wallet.LocalWallet, error)
 
 func provideEthereumClient(config *config.Config) (*ethclient.EthereumClient, error) {
 	ethereum := ethclient.NewEthereumClient()
-	err := ethereum.Initialize(&config.Ethereum)
+	var err error
+	if config.IsFollower() {
+		err = ethereum.InitializeReadOnly(&config.Ethereum)
+	} else {
+		err = ethereum.Initialize(&config.Ethereum)
+	}
 	if err != nil {
 		return nil, xerrors.Errorf("failed to initialize ethereum client: %v", err)
 	}
@@ -314,13 +945,45 @@ func provideEthereumClient(config *config.Config) (*ethclient.EthereumClient, er
 }
 
 func (n *Node) setupRPCClients() error {
-	fc := filecoin.NewLotusClient()
+	ethTransport, err := rpc.NewTransport(&n.Config.Ethereum.RPCTLS, n.Config.OutboundProxy)
+	if err != nil {
+		return xerrors.Errorf("invalid ethereum rpc transport config: %w", err)
+	}
+	ethRPC, err := ethereum.NewEthereumRPCClient(n.Config.Ethereum.GatewayAddress, ethTransport, n.Config.Ethereum.Confirmations)
+	if err != nil {
+		return xerrors.Errorf("failed to setup ethereum rpc client: %w", err)
+	}
+	n.EthereumRPC = ethRPC
+	rpc.RegisterAnchoredRPC(rtypes.RPCTypeEthereum, map[string]func(string) ([]byte, *reorg.Anchor, error){
+		"getTransaction":      ethRPC.GetTransaction,
+		"getTokenBalance":     ethRPC.GetTokenBalance,
+		"getTokenTotalSupply": ethRPC.GetTokenTotalSupply,
+		"getTxInclusionProof": ethRPC.GetTxInclusionProof,
+	})
+	validation.RegisterValidationMethod(rtypes.RPCTypeEthereum, "getTxInclusionProof", ethvalidation.NewTxInclusionProofValidator(ethRPC))
+
+	var lotusHosts []string
+	if n.Config.Filecoin.LotusHost != "" {
+		lotusHosts = append(lotusHosts, n.Config.Filecoin.LotusHost)
+	}
+	lotusHosts = append(lotusHosts, n.Config.Filecoin.LotusHosts...)
+	lotusTransport, err := rpc.NewTransport(&n.Config.Filecoin.TLS, n.Config.OutboundProxy)
+	if err != nil {
+		return xerrors.Errorf("invalid filecoin transport config: %w", err)
+	}
+	fc := filecoin.NewLotusClient(lotusHosts, n.Config.Filecoin.LotusToken, lotusTransport, n.Config.Filecoin.Confirmations)
+	n.Lotus = fc
 	rpc.RegisterRPC(rtypes.RPCTypeFilecoin, map[string]func(string) ([]byte, error){
 		"getTransaction": fc.GetTransaction,
 		"getBlock":       fc.GetBlock,
+		"getStorageDeal": fc.GetStorageDeal,
 	})
 
-	sl := solana2.NewSolanaClient()
+	solanaTransport, err := rpc.NewTransport(&n.Config.Solana.TLS, n.Config.OutboundProxy)
+	if err != nil {
+		return xerrors.Errorf("invalid solana transport config: %w", err)
+	}
+	sl := solana2.NewSolanaClient(solanaTransport)
 	rpc.RegisterRPC(rtypes.RPCTypeSolana, map[string]func(string) ([]byte, error){
 		"getTransaction": sl.GetTransaction,
 	})
@@ -328,15 +991,80 @@ func (n *Node) setupRPCClients() error {
 	return nil
 }
 
-func providePubsubRouter(lhost host.Host, config *config.Config) *pubsub2.PubSubRouter {
-	return pubsub2.NewPubSubRouter(lhost, config.PubSub.ServiceTopicName, config.IsBootstrap)
+func (n *Node) setupRandomnessRPC() error {
+	bc, ok := n.Beacon.BeaconNetworkForRound(0).(*drand.DrandBeacon)
+	if !ok {
+		return xerrors.Errorf("random beacon doesn't support randomness requests")
+	}
+	rpc.RegisterRPC(rtypes.RPCTypeDrand, map[string]func(string) ([]byte, error){
+		"getRandomness": bc.GetRandomness,
+	})
+	return nil
 }
 
-func provideConsensusManager(psb *pubsub2.PubSubRouter, miner *consensus.Miner, ethClient *ethclient.EthereumClient, privateKey []byte, minApprovals int, evc cache.EventCache) *consensus.PBFTConsensusManager {
-	return consensus.NewPBFTConsensusManager(psb, minApprovals, privateKey, ethClient, miner, evc)
+func provideDirectMessenger(lhost host.Host) *directmsg.Messenger {
+	return directmsg.NewMessenger(lhost)
 }
 
-func provideLibp2pHost(config *config.Config, privateKey crypto.PrivKey, pexDiscoveryUpdateTime time.Duration) (host.Host, error) {
+// provideClockDriftMonitor creates the clock drift monitor comparing local clock with bootstrap nodes and known validators
+func provideClockDriftMonitor(config *config.Config, lhost host.Host, messenger *directmsg.Messenger, alerter *alerting.Alerter) (*clockdrift.Monitor, error) {
+	var references []peer.ID
+	for _, a := range config.BootstrapNodes {
+		maddr, err := multiaddr.NewMultiaddr(a)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid multiaddress of bootstrap node: %v", err)
+		}
+		if info, err := peer.AddrInfoFromP2pAddr(maddr); err == nil && info.ID != lhost.ID() {
+			references = append(references, info.ID)
+		}
+	}
+	for _, p := range config.ClockDrift.ReferencePeers {
+		id, err := peer.Decode(p)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid reference peer of clock drift monitor: %w", err)
+		}
+		references = append(references, id)
+	}
+	return clockdrift.NewMonitor(lhost, messenger, &config.ClockDrift, references, alerter), nil
+}
+
+func provideMetricsRing(config *config.Config, dataDir *datadir.DataDir) (*metrics.Ring, error) {
+	return metrics.NewRing(dataDir.MetricsDir(), config.MetricsSnapshots.MaxFiles)
+}
+
+func provideMessageStore(config *config.Config, dataDir *datadir.DataDir) (*msgstore.Store, error) {
+	return msgstore.Open(dataDir.MessageStoreDir(), time.Duration(config.MessageRetention)*time.Second)
+}
+
+func providePubsubRouter(lhost host.Host, config *config.Config, messages *msgstore.Store) *pubsub2.PubSubRouter {
+	return pubsub2.NewPubSubRouter(lhost, config.PubSub.ServiceTopicName, config.IsBootstrap || config.IsSeed(), messages)
+}
+
+func provideConsensusManager(psb *pubsub2.PubSubRouter, miner *consensus.Miner, ethClient *ethclient.EthereumClient, privateKey []byte, minApprovals int, evc cache.EventCache, faults []string, deadLetters *deadletter.Queue, alerter *alerting.Alerter, auditLog *audit.Log, reorgs *reorg.Monitor) *consensus.PBFTConsensusManager {
+	return consensus.NewPBFTConsensusManager(psb, minApprovals, privateKey, ethClient, miner, evc, faults, deadLetters, alerter, auditLog, reorgs)
+}
+
+func provideBanlist(config *config.Config, dataDir *datadir.DataDir) (*banlist.Banlist, error) {
+	var trusted []peer.ID
+	for _, p := range config.Banlist.TrustedPublishers {
+		id, err := peer.Decode(p)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid trusted publisher of banlist: %w", err)
+		}
+		trusted = append(trusted, id)
+	}
+	return banlist.NewBanlist(dataDir.BanlistPath(), trusted)
+}
+
+func provideBanlistFeeds(config *config.Config, bans *banlist.Banlist, h host.Host) *banlist.Feeds {
+	return banlist.NewFeeds(bans, config.Banlist.Feeds, time.Duration(config.Banlist.RefreshInterval)*time.Second, func(id peer.ID) {
+		if err := h.Network().ClosePeer(id); err != nil {
+			logrus.Warnf("Failed to disconnect banned peer %s: %v", id, err)
+		}
+	})
+}
+
+func provideLibp2pHost(config *config.Config, privateKey crypto.PrivKey, gater connmgr.ConnectionGater, pexDiscoveryUpdateTime time.Duration) (host.Host, error) {
 	listenMultiAddr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%d", config.ListenAddr, config.ListenPort))
 	if err != nil {
 		return nil, xerrors.Errorf("failed to parse multiaddress: %v", err)
@@ -345,6 +1073,7 @@ func provideLibp2pHost(config *config.Config, privateKey crypto.PrivKey, pexDisc
 		context.TODO(),
 		libp2p.ListenAddrs(listenMultiAddr),
 		libp2p.Identity(privateKey),
+		libp2p.ConnectionGater(gater),
 	)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to setup libp2p host: %v", err)
@@ -381,37 +1110,68 @@ func Start() {
 	flag.Parse()
 
 	if *configPath == "" {
-		logrus.Fatal("no config path provided")
+		logrus.Info("No config path provided, using defaults and DIONE_* environment variables")
 	}
 	cfg, err := config.NewConfig(*configPath)
 	if err != nil {
 		logrus.Fatalf("failed to load config: %v", err)
 	}
 
-	var privateKey crypto.PrivKey
+	dataDir, err := datadir.Open(cfg.DataDir)
+	if err != nil {
+		logrus.Fatalf("failed to open data directory: %v", err)
+	}
+	defer dataDir.Close()
 
-	if cfg.IsBootstrap {
-		if _, err := os.Stat(".bootstrap_privkey"); os.IsNotExist(err) {
-			privateKey, err = generatePrivateKey()
-			if err != nil {
-				logrus.Fatal(err)
-			}
+	logFile, err := setupLogOutput(&cfg.Logging, dataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	for _, key := range cfg.DeprecatedKeys() {
+		logrus.Warnf("Config field %s is deprecated, use %s instead", key.Key, key.ReplacedBy)
+	}
 
-			f, _ := os.Create(".bootstrap_privkey")
-			r, _ := privateKey.Raw()
-			f.Write(r)
-		} else {
-			pkey, _ := ioutil.ReadFile(".bootstrap_privkey")
-			privateKey, _ = crypto.UnmarshalEd25519PrivateKey(pkey)
+	shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing)
+	if err != nil {
+		logrus.Fatalf("failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logrus.Errorf("Failed to flush traces: %v", err)
 		}
-	} else {
+	}()
+
+	if cfg.IsBootstrap {
+		imported, err := keystore.ImportLegacyBootstrapKey(dataDir, keystore.LegacyBootstrapKeyPath)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if imported {
+			logrus.Warnf("Bootstrap key %s has been imported into %s, the legacy file can be removed",
+				keystore.LegacyBootstrapKeyPath, dataDir.KeyPath(keystore.BootstrapKeyName))
+		}
+	}
+	privateKey, err := keystore.LoadIdentityKey(dataDir)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if privateKey == nil {
 		privateKey, err = generatePrivateKey()
 		if err != nil {
 			logrus.Fatal(err)
 		}
+		// bootstrap and seed nodes must keep their peer IDs, since other nodes dial them by multiaddr
+		if cfg.IsBootstrap || cfg.IsSeed() {
+			if err := keystore.SaveIdentityKey(dataDir, privateKey); err != nil {
+				logrus.Fatal(err)
+			}
+		}
 	}
 
-	node, err := NewNode(cfg, privateKey, DefaultPEXUpdateTime)
+	node, err := NewNode(cfg, dataDir, privateKey, DefaultPEXUpdateTime)
 	if err != nil {
 		logrus.Fatal(err)
 	}
@@ -425,14 +1185,62 @@ func Start() {
 
 	//log.SetDebugLogging()
 
-	//ctx, ctxCancel := context.WithCancel(context.Background())
-	//node.GlobalCtx = ctx
-	//node.GlobalCtxCancel = ctxCancel
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	node.GlobalCtx = ctx
+	node.GlobalCtxCancel = ctxCancel
 
-	err = node.Run(context.TODO())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logrus.Infof("Received %s, shutting down...", sig)
+		ctxCancel()
+	}()
+
+	err = node.Run(ctx)
 	if err != nil {
 		logrus.Fatal(err)
 	}
+
+	shutdownTimeout := time.Duration(cfg.ShutdownTimeout) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if err := node.Shutdown(shutdownCtx); err != nil {
+		logrus.Errorf("Node hasn't shut down cleanly: %v", err)
+		return
+	}
+	logrus.Info("Node has shut down")
+}
+
+// setupLogOutput directs the log to configured outputs. It returns the log file writer
+// which must be closed on exit, or nil if the log isn't written to a file.
+func setupLogOutput(cfg *config.LoggingConfig, dataDir *datadir.DataDir) (*logfile.Writer, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	path := cfg.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dataDir.LogsDir(), path)
+	}
+	w, err := logfile.Open(path, logfile.Options{
+		MaxSize:        int64(cfg.MaxSize) << 20,
+		RotateInterval: time.Duration(cfg.RotateInterval) * time.Second,
+		MaxBackups:     cfg.MaxBackups,
+		MaxAge:         time.Duration(cfg.MaxAge) * time.Second,
+		Compress:       cfg.Compress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Console {
+		logrus.SetOutput(io.MultiWriter(os.Stderr, w))
+	} else {
+		logrus.SetOutput(w)
+	}
+	return w, nil
 }
 
 func generatePrivateKey() (crypto.PrivKey, error) {
@@ -7,6 +7,7 @@ import (
 	"github.com/fxamacker/cbor/v2"
 
 	"github.com/Secured-Finance/dione/consensus/types"
+	"github.com/Secured-Finance/dione/msgstore"
 
 	host "github.com/libp2p/go-libp2p-core/host"
 	peer "github.com/libp2p/go-libp2p-core/peer"
@@ -14,6 +15,15 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// messageDecMode bounds the resources spent on decoding of messages received from untrusted peers.
+// Consensus messages contain only a few short arrays, so anything larger is rejected early.
+var messageDecMode, _ = cbor.DecOptions{
+	DupMapKey:        cbor.DupMapKeyEnforcedAPF,
+	MaxNestedLevels:  16,
+	MaxArrayElements: 1024,
+	MaxMapPairs:      64,
+}.DecMode()
+
 type PubSubRouter struct {
 	node                host.Host
 	Pubsub              *pubsub.PubSub
@@ -23,9 +33,12 @@ type PubSubRouter struct {
 	handlers            map[types.MessageType][]Handler
 	oracleTopicName     string
 	oracleTopic         *pubsub.Topic
+	messages            *msgstore.Store
 }
 
-func NewPubSubRouter(h host.Host, oracleTopic string, isBootstrap bool) *PubSubRouter {
+// NewPubSubRouter creates the router of consensus messages, messages published by the node and received
+// messages accepted by handlers are kept in the message store if it's not nil
+func NewPubSubRouter(h host.Host, oracleTopic string, isBootstrap bool, messages *msgstore.Store) *PubSubRouter {
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	psr := &PubSubRouter{
@@ -33,6 +46,7 @@ func NewPubSubRouter(h host.Host, oracleTopic string, isBootstrap bool) *PubSubR
 		context:       ctx,
 		contextCancel: ctxCancel,
 		handlers:      make(map[types.MessageType][]Handler),
+		messages:      messages,
 	}
 
 	var pbOptions []pubsub.Option
@@ -81,7 +95,11 @@ func NewPubSubRouter(h host.Host, oracleTopic string, isBootstrap bool) *PubSubR
 				{
 					msg, err := subscription.Next(psr.context)
 					if err != nil {
+						if psr.context.Err() != nil {
+							return
+						}
 						logrus.Warnf("Failed to receive pubsub message: %v", err)
+						continue
 					}
 					psr.handleMessage(msg)
 				}
@@ -103,15 +121,16 @@ func (psr *PubSubRouter) handleMessage(p *pubsub.Message) {
 		return
 	}
 	var message types.Message
-	err = cbor.Unmarshal(p.Data, &message)
+	err = messageDecMode.Unmarshal(p.Data, &message)
 	if err != nil {
 		logrus.Warn("Unable to decode message data! " + err.Error())
 		return
 	}
 	message.From = senderPeerID
+	message.Raw = p.Data
 	handlers, ok := psr.handlers[message.Type]
 	if !ok {
-		logrus.Warn("Dropping message " + string(message.Type) + " because we don't have any handlers!")
+		logrus.Warnf("Dropping message of type %d because we don't have any handlers!", message.Type)
 		return
 	}
 	for _, v := range handlers {
@@ -133,9 +152,41 @@ func (psr *PubSubRouter) BroadcastToServiceTopic(msg *types.Message) error {
 		return err
 	}
 	err = psr.oracleTopic.Publish(context.TODO(), data)
+	if err == nil {
+		psr.storeMessage(msg, data, true)
+	}
 	return err
 }
 
+// Store keeps the received message in the message store. Handlers call it once the message is validated,
+// so peers can't fill the store with messages which are dropped anyway.
+func (psr *PubSubRouter) Store(msg *types.Message) {
+	if psr == nil || msg.Raw == nil {
+		return
+	}
+	psr.storeMessage(msg, msg.Raw, false)
+}
+
+func (psr *PubSubRouter) storeMessage(msg *types.Message, data []byte, outgoing bool) {
+	if psr.messages == nil {
+		return
+	}
+	from := msg.From
+	if outgoing {
+		from = psr.node.ID()
+	}
+	err := psr.messages.Append(&msgstore.Record{
+		From:        from,
+		Outgoing:    outgoing,
+		Type:        msg.Type,
+		ConsensusID: msg.Payload.Task.ConsensusID,
+		Data:        data,
+	})
+	if err != nil {
+		logrus.Warnf("Failed to store consensus message: %v", err)
+	}
+}
+
 func (psr *PubSubRouter) Shutdown() {
 	psr.contextCancel()
 }
@@ -0,0 +1,107 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/consensus/types"
+	types2 "github.com/Secured-Finance/dione/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSubRouterHostileMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// gossipsub verifies message signatures, so peers need real keys instead of mocknet's bogus ones
+	mn := mocknet.New(ctx)
+	for i := 0; i < 2; i++ {
+		sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = mn.AddPeer(sk, ma.StringCast(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 4000+i)))
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+	if !assert.NoError(t, mn.LinkAll()) || !assert.NoError(t, mn.ConnectAllButSelf()) {
+		return
+	}
+	hosts := mn.Hosts()
+	router := NewPubSubRouter(hosts[0], "dione-test", false, nil)
+	defer router.Shutdown()
+
+	received := make(chan *types.Message, 16)
+	router.Hook(types.MessageTypeCommit, func(msg *types.Message) {
+		received <- msg
+	})
+
+	// the hostile peer publishes raw data bypassing the router encoding
+	ps, err := pubsub.NewGossipSub(ctx, hosts[1])
+	if !assert.NoError(t, err) {
+		return
+	}
+	topic, err := ps.Join("dione-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 0; len(topic.ListPeers()) == 0; i++ {
+		if i == 100 {
+			t.Fatal("peers haven't joined the topic")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	encode := func(msg *types.Message) []byte {
+		data, err := cbor.Marshal(msg)
+		assert.NoError(t, err)
+		return data
+	}
+	giant := &types.Message{Type: types.MessageTypeCommit}
+	giant.Payload.Task.Miner = hosts[1].ID()
+	giant.Payload.Task.BeaconEntries = make([]types2.BeaconEntry, 5000)
+	nested := []byte{}
+	for i := 0; i < 100; i++ {
+		nested = append(nested, 0x81) // array of single element
+	}
+	nested = append(nested, 0x00)
+
+	hostile := [][]byte{
+		nil,
+		[]byte("garbage"),
+		{0xbf, 0xff}, // empty indefinite-length map
+		{0xa2, 0x64, 'T', 'y', 'p', 'e', 0x03, 0x64, 'T', 'y', 'p', 'e', 0x03}, // duplicate keys
+		nested,
+		encode(giant),
+		encode(&types.Message{Type: 42, Payload: types.ConsensusMessage{Task: types2.DioneTask{Miner: hosts[1].ID()}}}),
+	}
+	for _, data := range hostile {
+		assert.NoError(t, topic.Publish(ctx, data))
+	}
+	valid := &types.Message{Type: types.MessageTypeCommit}
+	valid.Payload.Task.Miner = hosts[1].ID()
+	valid.Payload.Task.RequestID = "1"
+	assert.NoError(t, topic.Publish(ctx, encode(valid)))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "1", msg.Payload.Task.RequestID)
+		assert.Equal(t, hosts[1].ID(), msg.From)
+	case <-time.After(5 * time.Second):
+		t.Fatal("valid message wasn't received after hostile ones")
+	}
+	select {
+	case msg := <-received:
+		t.Fatalf("hostile message is passed to the handler: %+v", msg.Payload.Task)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,144 @@
+package reorg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/alerting"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AlertSourceReorg = "source_chain_reorg"
+
+	DefaultCheckInterval = 15 * time.Second
+	// MaxTrackDuration is how long the answer is tracked if it's never submitted by this node
+	MaxTrackDuration = time.Hour
+)
+
+// Anchor is the source chain block the oracle answer is based on
+type Anchor struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// HeaderSource returns hash of the canonical block with specified number
+type HeaderSource func(ctx context.Context, number uint64) (common.Hash, error)
+
+type trackedAnswer struct {
+	anchor    Anchor
+	trackedAt time.Time
+}
+
+// Monitor tracks source chain blocks of answers which aren't submitted yet
+// and flags the answers whose blocks were reorganized out of the canonical chain.
+type Monitor struct {
+	source        HeaderSource
+	checkInterval time.Duration
+	alerter       *alerting.Alerter
+
+	mutex   sync.Mutex
+	tracked map[string]*trackedAnswer
+	reorged map[string]struct{}
+}
+
+func NewMonitor(source HeaderSource, checkInterval time.Duration, alerter *alerting.Alerter) *Monitor {
+	if checkInterval <= 0 {
+		checkInterval = DefaultCheckInterval
+	}
+	return &Monitor{
+		source:        source,
+		checkInterval: checkInterval,
+		alerter:       alerter,
+		tracked:       map[string]*trackedAnswer{},
+		reorged:       map[string]struct{}{},
+	}
+}
+
+// Track starts tracking the anchor block of request answer
+func (m *Monitor) Track(requestID string, anchor Anchor) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.tracked[requestID] = &trackedAnswer{anchor: anchor, trackedAt: time.Now()}
+	delete(m.reorged, requestID)
+}
+
+// Untrack stops tracking of request answer, e.g. when it's submitted
+func (m *Monitor) Untrack(requestID string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.tracked, requestID)
+	delete(m.reorged, requestID)
+}
+
+// IsReorged reports whether the anchor block of request answer is no longer in the canonical chain
+func (m *Monitor) IsReorged(requestID string) bool {
+	if m == nil {
+		return false
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, ok := m.reorged[requestID]
+	return ok
+}
+
+// Run starts periodic checks of tracked anchors, it blocks until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *Monitor) check(ctx context.Context) {
+	m.mutex.Lock()
+	tracked := make(map[string]Anchor, len(m.tracked))
+	for id, t := range m.tracked {
+		if time.Since(t.trackedAt) > MaxTrackDuration {
+			delete(m.tracked, id)
+			delete(m.reorged, id)
+			continue
+		}
+		if _, ok := m.reorged[id]; !ok {
+			tracked[id] = t.anchor
+		}
+	}
+	m.mutex.Unlock()
+
+	for id, anchor := range tracked {
+		hash, err := m.source(ctx, anchor.Number)
+		if err != nil {
+			logrus.Warnf("Failed to get source chain block %d: %v", anchor.Number, err)
+			continue
+		}
+		if hash == anchor.Hash {
+			continue
+		}
+
+		m.mutex.Lock()
+		if _, ok := m.tracked[id]; ok {
+			m.reorged[id] = struct{}{}
+		}
+		m.mutex.Unlock()
+
+		msg := fmt.Sprintf("block %d (%s) the answer of request %s is based on was reorganized, canonical block is %s", anchor.Number, anchor.Hash.Hex(), id, hash.Hex())
+		logrus.Errorf("Source chain reorg detected: %s", msg)
+		m.alerter.Fire(AlertSourceReorg, id, alerting.SeverityCritical, msg)
+	}
+}
@@ -0,0 +1,39 @@
+package reorg
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorDetectsReorg(t *testing.T) {
+	var mutex sync.Mutex
+	canonical := map[uint64]common.Hash{
+		10: common.HexToHash("0x0a"),
+		11: common.HexToHash("0x0b"),
+	}
+	m := NewMonitor(func(ctx context.Context, number uint64) (common.Hash, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return canonical[number], nil
+	}, 0, nil)
+
+	m.Track("1", Anchor{Number: 10, Hash: common.HexToHash("0x0a")})
+	m.Track("2", Anchor{Number: 11, Hash: common.HexToHash("0x0b")})
+	m.check(context.Background())
+	assert.False(t, m.IsReorged("1"))
+	assert.False(t, m.IsReorged("2"))
+
+	mutex.Lock()
+	canonical[11] = common.HexToHash("0x1b")
+	mutex.Unlock()
+	m.check(context.Background())
+	assert.False(t, m.IsReorged("1"))
+	assert.True(t, m.IsReorged("2"))
+
+	m.Untrack("2")
+	assert.False(t, m.IsReorged("2"))
+}
@@ -0,0 +1,25 @@
+package ethereum
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"golang.org/x/xerrors"
+)
+
+// BlockHash returns hash of the canonical block with specified number. The hash is taken from the node
+// instead of hashing the header locally, since types.Header of this go-ethereum version lacks fields
+// of later forks and its hash doesn't match hashes of post-London blocks.
+func (erc *EthereumRPCClient) BlockHash(ctx context.Context, number uint64) (common.Hash, error) {
+	var block *struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := erc.rpc.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false); err != nil {
+		return common.Hash{}, xerrors.Errorf("failed to get block %d: %w", number, err)
+	}
+	if block == nil {
+		return common.Hash{}, xerrors.Errorf("block %d isn't found", number)
+	}
+	return block.Hash, nil
+}
@@ -0,0 +1,80 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockHash(t *testing.T) {
+	hash := common.HexToHash("0x56a9bb0302da44b8c0b3df540781424684c3af04d0b7a38d72842b762076a664")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []interface{}   `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "eth_getBlockByNumber", req.Method)
+		result := "null"
+		if req.Params[0] == "0xed14f2" {
+			// the hash is returned as is, it isn't recomputed from header fields
+			result = `{"number":"0xed14f2","baseFeePerGas":"0x1","hash":"` + hash.Hex() + `"}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	defer srv.Close()
+
+	erc, err := NewEthereumRPCClient(srv.URL, nil, 0)
+	require.NoError(t, err)
+
+	h, err := erc.BlockHash(context.Background(), 15537394)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, h)
+
+	_, err = erc.BlockHash(context.Background(), 15537395)
+	assert.Error(t, err)
+}
+
+func TestCheckTxInclusionProof(t *testing.T) {
+	hash := common.HexToHash("0x56a9bb0302da44b8c0b3df540781424684c3af04d0b7a38d72842b762076a664")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []interface{}   `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		result := "null"
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			if req.Params[0] == "0x64" {
+				result = `{"number":"0x64","hash":"` + hash.Hex() + `"}`
+			}
+		case "eth_blockNumber":
+			result = `"0x69"`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	defer srv.Close()
+
+	erc, err := NewEthereumRPCClient(srv.URL, nil, 6)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, erc.CheckTxInclusionProof(ctx, &TxInclusionProof{BlockNumber: 100, BlockHash: hash}))
+	assert.Error(t, erc.CheckTxInclusionProof(ctx, &TxInclusionProof{BlockNumber: 100, BlockHash: common.HexToHash("0x01")}), "proof of fabricated header")
+	assert.Error(t, erc.CheckTxInclusionProof(ctx, &TxInclusionProof{BlockNumber: 101, BlockHash: hash}), "proof of unknown block")
+
+	erc, err = NewEthereumRPCClient(srv.URL, nil, 10)
+	require.NoError(t, err)
+	assert.Error(t, erc.CheckTxInclusionProof(ctx, &TxInclusionProof{BlockNumber: 100, BlockHash: hash}), "block without required confirmations")
+}
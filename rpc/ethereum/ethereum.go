@@ -2,34 +2,163 @@ package ethereum
 
 import (
 	"context"
+	"math/big"
+	"strings"
+
+	"github.com/Secured-Finance/dione/reorg"
+	drpc "github.com/Secured-Finance/dione/rpc"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/xerrors"
+)
+
+// TokenAmountSize is the size of ABI-encoded uint256 returned by token queries
+const TokenAmountSize = 32
+
+var (
+	erc20BalanceOfSelector   = common.FromHex("0x70a08231") // balanceOf(address)
+	erc20TotalSupplySelector = common.FromHex("0x18160ddd") // totalSupply()
 )
 
 type EthereumRPCClient struct {
-	client *ethclient.Client
+	client        *ethclient.Client
+	rpc           *rpc.Client
+	confirmations uint64
 }
 
-func NewEthereumRPCClient(url string) (*EthereumRPCClient, error) {
-	client, err := ethclient.Dial(url)
+// NewEthereumRPCClient creates the client which answers only about blocks having at least specified count of confirmations
+func NewEthereumRPCClient(url string, transport *drpc.Transport, confirmations uint64) (*EthereumRPCClient, error) {
+	rpcClient, err := dial(url, transport)
 	if err != nil {
 		return nil, err
 	}
 	return &EthereumRPCClient{
-		client: client,
+		client:        ethclient.NewClient(rpcClient),
+		rpc:           rpcClient,
+		confirmations: confirmations,
 	}, nil
 }
 
-func (erc *EthereumRPCClient) GetTransaction(txHash string) ([]byte, error) {
+func dial(url string, transport *drpc.Transport) (*rpc.Client, error) {
+	if transport == nil {
+		return rpc.Dial(url)
+	}
+	switch {
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return rpc.DialHTTPWithClient(url, transport.HTTPClient())
+	case strings.HasPrefix(url, "ws://"), strings.HasPrefix(url, "wss://"):
+		return rpc.DialWebsocketWithDialer(context.Background(), url, "", transport.WebsocketDialer())
+	default:
+		return rpc.Dial(url)
+	}
+}
+
+// GetTransaction returns JSON of the transaction and the block it's included into
+func (erc *EthereumRPCClient) GetTransaction(txHash string) ([]byte, *reorg.Anchor, error) {
 	txHHash := common.HexToHash(txHash)
-	tx, _, err := erc.client.TransactionByHash(context.TODO(), txHHash)
+	tx, isPending, err := erc.client.TransactionByHash(context.TODO(), txHHash)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if isPending {
+		return nil, nil, xerrors.Errorf("transaction %s is pending", txHash)
+	}
+	receipt, err := erc.rawReceipt(context.TODO(), txHHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := erc.checkConfirmations(context.TODO(), uint64(receipt.BlockNumber)); err != nil {
+		return nil, nil, err
 	}
 	txRaw, err := tx.MarshalJSON()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return txRaw, &reorg.Anchor{Number: uint64(receipt.BlockNumber), Hash: receipt.BlockHash}, nil
+}
+
+// GetTokenBalance returns ERC-20 token balance of the holder at specified block.
+// Params format: "<token address>:<holder address>:<block number>"
+func (erc *EthereumRPCClient) GetTokenBalance(params string) ([]byte, *reorg.Anchor, error) {
+	p := strings.Split(params, ":")
+	if len(p) != 3 {
+		return nil, nil, xerrors.Errorf("invalid params format, expected <token>:<holder>:<block>")
+	}
+	if !common.IsHexAddress(p[0]) || !common.IsHexAddress(p[1]) {
+		return nil, nil, xerrors.Errorf("invalid token or holder address")
+	}
+	blockNumber, ok := new(big.Int).SetString(p[2], 10)
+	if !ok {
+		return nil, nil, xerrors.Errorf("invalid block number: %s", p[2])
+	}
+
+	data := append(common.CopyBytes(erc20BalanceOfSelector), common.LeftPadBytes(common.HexToAddress(p[1]).Bytes(), 32)...)
+	return erc.callToken(common.HexToAddress(p[0]), data, blockNumber)
+}
+
+// GetTokenTotalSupply returns total supply of ERC-20 token at specified block.
+// Params format: "<token address>:<block number>"
+func (erc *EthereumRPCClient) GetTokenTotalSupply(params string) ([]byte, *reorg.Anchor, error) {
+	p := strings.Split(params, ":")
+	if len(p) != 2 {
+		return nil, nil, xerrors.Errorf("invalid params format, expected <token>:<block>")
+	}
+	if !common.IsHexAddress(p[0]) {
+		return nil, nil, xerrors.Errorf("invalid token address")
+	}
+	blockNumber, ok := new(big.Int).SetString(p[1], 10)
+	if !ok {
+		return nil, nil, xerrors.Errorf("invalid block number: %s", p[1])
+	}
+
+	return erc.callToken(common.HexToAddress(p[0]), common.CopyBytes(erc20TotalSupplySelector), blockNumber)
+}
+
+// callToken does eth_call to the token contract and returns result as 32-byte big-endian uint256.
+// The call is made at the hash of the block, so the result is based exactly on the returned anchor block.
+func (erc *EthereumRPCClient) callToken(token common.Address, data []byte, blockNumber *big.Int) ([]byte, *reorg.Anchor, error) {
+	if !blockNumber.IsUint64() {
+		return nil, nil, xerrors.Errorf("invalid block number: %s", blockNumber)
+	}
+	ctx := context.TODO()
+	if err := erc.checkConfirmations(ctx, blockNumber.Uint64()); err != nil {
+		return nil, nil, err
+	}
+	blockHash, err := erc.BlockHash(ctx, blockNumber.Uint64())
+	if err != nil {
+		return nil, nil, err
+	}
+	var res hexutil.Bytes
+	err = erc.rpc.CallContext(ctx, &res, "eth_call", map[string]interface{}{
+		"to":   token,
+		"data": hexutil.Bytes(data),
+	}, map[string]interface{}{
+		"blockHash":        blockHash,
+		"requireCanonical": true,
+	})
+	if err != nil {
+		return nil, nil, xerrors.Errorf("eth_call to token contract failed: %w", err)
+	}
+	if len(res) != TokenAmountSize {
+		return nil, nil, xerrors.Errorf("unexpected token contract reply length: %d", len(res))
+	}
+	return res, &reorg.Anchor{Number: blockNumber.Uint64(), Hash: blockHash}, nil
+}
+
+// checkConfirmations ensures that the block is deep enough in the chain, so the answer can't be reverted by reorg
+func (erc *EthereumRPCClient) checkConfirmations(ctx context.Context, blockNumber uint64) error {
+	if erc.confirmations == 0 {
+		return nil
+	}
+	head, err := erc.client.BlockNumber(ctx)
+	if err != nil {
+		return xerrors.Errorf("failed to get latest block number: %w", err)
+	}
+	if blockNumber > head || head-blockNumber+1 < erc.confirmations {
+		return xerrors.Errorf("block %d doesn't have required %d confirmations yet (head is %d)", blockNumber, erc.confirmations, head)
 	}
-	return txRaw, nil
+	return nil
 }
@@ -0,0 +1,312 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Secured-Finance/dione/reorg"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// positions of fields in RLP-encoded block header
+	headerTxRootIndex       = 4
+	headerReceiptsRootIndex = 5
+	headerNumberIndex       = 8
+	headerMinFields         = 15
+)
+
+// TxInclusionProof proves that transaction is included into the block and has specified status.
+// The block header is included into the proof, so its hash ties transactions and receipts roots
+// to the block, transaction and receipt are proven by merkle proofs against these roots.
+type TxInclusionProof struct {
+	TxHash       common.Hash
+	BlockHash    common.Hash
+	BlockNumber  uint64
+	TxIndex      uint64
+	Status       uint64
+	Header       []byte // RLP-encoded block header
+	TxProof      [][]byte
+	ReceiptProof [][]byte
+}
+
+// proofList collects trie nodes written by trie.Prove
+type proofList [][]byte
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, common.CopyBytes(value))
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	return errors.New("proofList doesn't support deletion")
+}
+
+// GetTxInclusionProof verifies transaction inclusion and status against the block header
+// and returns RLP-encoded TxInclusionProof and the block it's made for
+func (erc *EthereumRPCClient) GetTxInclusionProof(txHash string) ([]byte, *reorg.Anchor, error) {
+	ctx := context.TODO()
+	hash := common.HexToHash(txHash)
+
+	receipt, err := erc.rawReceipt(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := erc.checkConfirmations(ctx, uint64(receipt.BlockNumber)); err != nil {
+		return nil, nil, err
+	}
+	block, err := erc.rawBlock(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := block.encodeHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txs := make([][]byte, 0, len(block.Transactions))
+	receipts := make([][]byte, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		enc, err := tx.encode()
+		if err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, enc)
+
+		r, err := erc.rawReceipt(ctx, tx.Hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc, err = r.encode()
+		if err != nil {
+			return nil, nil, err
+		}
+		receipts = append(receipts, enc)
+	}
+
+	proof, err := buildTxInclusionProof(header, txs, receipts, uint(receipt.TransactionIndex))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := rlp.EncodeToBytes(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, &reorg.Anchor{Number: proof.BlockNumber, Hash: proof.BlockHash}, nil
+}
+
+// buildTxInclusionProof creates the proof of transaction with specified index from the block header
+// and consensus encodings of all transactions and receipts of the block
+func buildTxInclusionProof(header []byte, txs, receipts [][]byte, index uint) (*TxInclusionProof, error) {
+	if int(index) >= len(txs) || len(receipts) != len(txs) {
+		return nil, xerrors.Errorf("transaction index %d is out of range", index)
+	}
+	txRoot, receiptsRoot, number, err := decodeHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	txTrie, err := buildTrie(txs)
+	if err != nil {
+		return nil, err
+	}
+	if txTrie.Hash() != txRoot {
+		return nil, xerrors.Errorf("transactions root mismatch: block has %s, computed %s", txRoot.Hex(), txTrie.Hash().Hex())
+	}
+	receiptTrie, err := buildTrie(receipts)
+	if err != nil {
+		return nil, err
+	}
+	if receiptTrie.Hash() != receiptsRoot {
+		return nil, xerrors.Errorf("receipts root mismatch: block has %s, computed %s", receiptsRoot.Hex(), receiptTrie.Hash().Hex())
+	}
+	status, err := receiptStatus(receipts[index])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rlp.EncodeToBytes(index)
+	if err != nil {
+		return nil, err
+	}
+	proof := &TxInclusionProof{
+		TxHash:      crypto.Keccak256Hash(txs[index]),
+		BlockHash:   crypto.Keccak256Hash(header),
+		BlockNumber: number,
+		TxIndex:     uint64(index),
+		Status:      status,
+		Header:      header,
+	}
+	var txProof, receiptProof proofList
+	if err := txTrie.Prove(key, 0, &txProof); err != nil {
+		return nil, xerrors.Errorf("failed to create transaction proof: %w", err)
+	}
+	if err := receiptTrie.Prove(key, 0, &receiptProof); err != nil {
+		return nil, xerrors.Errorf("failed to create receipt proof: %w", err)
+	}
+	proof.TxProof = txProof
+	proof.ReceiptProof = receiptProof
+
+	if err := proof.Verify(); err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// Verify checks that the header hashes to the block hash and merkle proofs of transaction
+// and its receipt against transactions and receipts roots of the header
+func (p *TxInclusionProof) Verify() error {
+	if crypto.Keccak256Hash(p.Header) != p.BlockHash {
+		return xerrors.Errorf("header doesn't match block hash %s", p.BlockHash.Hex())
+	}
+	txRoot, receiptsRoot, number, err := decodeHeader(p.Header)
+	if err != nil {
+		return err
+	}
+	if number != p.BlockNumber {
+		return xerrors.Errorf("header number %d doesn't match block number %d", number, p.BlockNumber)
+	}
+
+	key, err := rlp.EncodeToBytes(uint(p.TxIndex))
+	if err != nil {
+		return err
+	}
+
+	txRLP, err := verifyProof(txRoot, key, p.TxProof)
+	if err != nil {
+		return xerrors.Errorf("invalid transaction proof: %w", err)
+	}
+	if crypto.Keccak256Hash(txRLP) != p.TxHash {
+		return xerrors.Errorf("proven transaction doesn't match transaction hash %s", p.TxHash.Hex())
+	}
+
+	receiptRLP, err := verifyProof(receiptsRoot, key, p.ReceiptProof)
+	if err != nil {
+		return xerrors.Errorf("invalid receipt proof: %w", err)
+	}
+	status, err := receiptStatus(receiptRLP)
+	if err != nil {
+		return xerrors.Errorf("failed to decode proven receipt: %w", err)
+	}
+	if status != p.Status {
+		return xerrors.Errorf("proven receipt status %d doesn't match claimed status %d", status, p.Status)
+	}
+
+	return nil
+}
+
+// DecodeTxInclusionProof decodes and verifies RLP-encoded TxInclusionProof
+func DecodeTxInclusionProof(payload []byte) (*TxInclusionProof, error) {
+	var p TxInclusionProof
+	if err := rlp.DecodeBytes(payload, &p); err != nil {
+		return nil, xerrors.Errorf("failed to decode transaction inclusion proof: %w", err)
+	}
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CheckTxInclusionProof checks the proof against the chain as this node sees it. Proofs are verified
+// against the header they carry, so the block of the proof must be canonical by this node and have
+// required confirmations, otherwise the miner could prove anything with a fabricated header.
+func (erc *EthereumRPCClient) CheckTxInclusionProof(ctx context.Context, proof *TxInclusionProof) error {
+	hash, err := erc.BlockHash(ctx, proof.BlockNumber)
+	if err != nil {
+		return err
+	}
+	if hash != proof.BlockHash {
+		return xerrors.Errorf("block %d is %s, proof is for block %s", proof.BlockNumber, hash.Hex(), proof.BlockHash.Hex())
+	}
+	return erc.checkConfirmations(ctx, proof.BlockNumber)
+}
+
+// decodeHeader returns roots and number of RLP-encoded block header. Headers of later forks
+// have more fields than types.Header knows, so only the fields needed for the proof are decoded.
+func decodeHeader(header []byte) (txRoot, receiptsRoot common.Hash, number uint64, err error) {
+	var fields []rlp.RawValue
+	if err = rlp.DecodeBytes(header, &fields); err != nil {
+		return txRoot, receiptsRoot, 0, xerrors.Errorf("failed to decode block header: %w", err)
+	}
+	if len(fields) < headerMinFields {
+		return txRoot, receiptsRoot, 0, xerrors.Errorf("block header has %d fields, expected at least %d", len(fields), headerMinFields)
+	}
+	if err = rlp.DecodeBytes(fields[headerTxRootIndex], &txRoot); err != nil {
+		return txRoot, receiptsRoot, 0, xerrors.Errorf("failed to decode transactions root: %w", err)
+	}
+	if err = rlp.DecodeBytes(fields[headerReceiptsRootIndex], &receiptsRoot); err != nil {
+		return txRoot, receiptsRoot, 0, xerrors.Errorf("failed to decode receipts root: %w", err)
+	}
+	if err = rlp.DecodeBytes(fields[headerNumberIndex], &number); err != nil {
+		return txRoot, receiptsRoot, 0, xerrors.Errorf("failed to decode block number: %w", err)
+	}
+	return txRoot, receiptsRoot, number, nil
+}
+
+// receiptStatus returns the status of consensus-encoded receipt. Typed receipts (EIP-2718)
+// are prefixed by the transaction type, which types.Receipt of this go-ethereum version can't decode.
+func receiptStatus(receipt []byte) (uint64, error) {
+	if len(receipt) != 0 && receipt[0] <= 0x7f {
+		receipt = receipt[1:]
+	}
+	var fields []rlp.RawValue
+	if err := rlp.DecodeBytes(receipt, &fields); err != nil {
+		return 0, xerrors.Errorf("failed to decode receipt: %w", err)
+	}
+	if len(fields) != 4 {
+		return 0, xerrors.Errorf("receipt has %d fields, expected 4", len(fields))
+	}
+	var statusOrRoot []byte
+	if err := rlp.DecodeBytes(fields[0], &statusOrRoot); err != nil {
+		return 0, xerrors.Errorf("failed to decode receipt status: %w", err)
+	}
+	switch {
+	case len(statusOrRoot) == 0:
+		return 0, nil
+	case len(statusOrRoot) == 1 && statusOrRoot[0] == 1:
+		return 1, nil
+	case len(statusOrRoot) == common.HashLength:
+		return 0, xerrors.Errorf("receipts before Byzantium don't have status")
+	default:
+		return 0, xerrors.Errorf("invalid receipt status %x", statusOrRoot)
+	}
+}
+
+func buildTrie(values [][]byte) (*trie.Trie, error) {
+	t, err := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create trie: %w", err)
+	}
+	for i, v := range values {
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			return nil, err
+		}
+		t.Update(key, v)
+	}
+	return t, nil
+}
+
+func verifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	db := memorydb.New()
+	for _, node := range proof {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	value, err := trie.VerifyProof(root, key, db)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, xerrors.Errorf("key is absent in the trie")
+	}
+	return value, nil
+}
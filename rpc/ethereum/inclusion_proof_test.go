@@ -0,0 +1,217 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBlock struct {
+	header   []byte
+	txs      [][]byte
+	receipts [][]byte
+}
+
+func newTestBlock(t *testing.T, txCount int) (*types.Block, *testBlock) {
+	var txs types.Transactions
+	var receipts types.Receipts
+	for i := 0; i < txCount; i++ {
+		tx := types.NewTransaction(uint64(i), common.BigToAddress(big.NewInt(int64(i))), big.NewInt(1), 21000, big.NewInt(1), nil)
+		txs = append(txs, tx)
+		receipts = append(receipts, &types.Receipt{
+			Status:            uint64(i % 2),
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			Logs:              []*types.Log{},
+		})
+	}
+	header := &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1)}
+	block := types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+
+	tb := &testBlock{}
+	var err error
+	tb.header, err = rlp.EncodeToBytes(block.Header())
+	require.NoError(t, err)
+	for i := range txs {
+		enc, err := rlp.EncodeToBytes(txs[i])
+		require.NoError(t, err)
+		tb.txs = append(tb.txs, enc)
+		enc, err = rlp.EncodeToBytes(receipts[i])
+		require.NoError(t, err)
+		tb.receipts = append(tb.receipts, enc)
+	}
+	return block, tb
+}
+
+// newTypedTestBlock builds post-London block with dynamic fee transaction, which neither
+// types.Header nor types.Transaction of this go-ethereum version can represent
+func newTypedTestBlock(t *testing.T) (*rpcTransaction, *testBlock) {
+	to := common.HexToAddress("0x5fbdb2315678afecb367f032d93f642f64180aa3")
+	tx := &rpcTransaction{
+		Type:                 txTypeDynamicFee,
+		ChainID:              (*hexutil.Big)(big.NewInt(1)),
+		Nonce:                7,
+		MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(2e9)),
+		MaxFeePerGas:         (*hexutil.Big)(big.NewInt(100e9)),
+		Gas:                  50000,
+		To:                   &to,
+		Value:                (*hexutil.Big)(big.NewInt(1e18)),
+		AccessList:           []rpcAccessTuple{{Address: to, StorageKeys: []common.Hash{{1}}}},
+		V:                    (*hexutil.Big)(big.NewInt(1)),
+		R:                    (*hexutil.Big)(big.NewInt(12345)),
+		S:                    (*hexutil.Big)(big.NewInt(67890)),
+	}
+	enc, err := rlp.EncodeToBytes([]interface{}{
+		big.NewInt(1), uint64(7), big.NewInt(2e9), big.NewInt(100e9), uint64(50000), to, big.NewInt(1e18), []byte{},
+		[]interface{}{[]interface{}{to, []common.Hash{{1}}}},
+		big.NewInt(1), big.NewInt(12345), big.NewInt(67890),
+	})
+	require.NoError(t, err)
+	txEnc := append([]byte{txTypeDynamicFee}, enc...)
+	tx.Hash = crypto.Keccak256Hash(txEnc)
+
+	status := hexutil.Uint64(1)
+	receipt := &rpcReceipt{
+		Type:              txTypeDynamicFee,
+		Status:            &status,
+		CumulativeGasUsed: 21000,
+		Bloom:             make([]byte, types.BloomByteLength),
+	}
+	receiptEnc, err := receipt.encode()
+	require.NoError(t, err)
+
+	tb := &testBlock{txs: [][]byte{txEnc}, receipts: [][]byte{receiptEnc}}
+	txTrie, err := buildTrie(tb.txs)
+	require.NoError(t, err)
+	receiptTrie, err := buildTrie(tb.receipts)
+	require.NoError(t, err)
+
+	block := &rpcBlock{
+		TxHash:      txTrie.Hash(),
+		ReceiptHash: receiptTrie.Hash(),
+		Bloom:       make([]byte, types.BloomByteLength),
+		Difficulty:  (*hexutil.Big)(big.NewInt(0)),
+		Number:      15537394,
+		GasLimit:    30000000,
+		GasUsed:     21000,
+		Time:        1663224179,
+		Nonce:       make([]byte, 8),
+		BaseFee:     (*hexutil.Big)(big.NewInt(7e9)),
+	}
+	header, err := rlp.EncodeToBytes([]interface{}{
+		block.ParentHash, block.UncleHash, block.Coinbase, block.Root, block.TxHash, block.ReceiptHash,
+		[]byte(block.Bloom), big.NewInt(0), uint64(15537394), uint64(30000000), uint64(21000), uint64(1663224179),
+		[]byte{}, block.MixDigest, make([]byte, 8), big.NewInt(7e9),
+	})
+	require.NoError(t, err)
+	block.Hash = crypto.Keccak256Hash(header)
+
+	tb.header, err = block.encodeHeader()
+	require.NoError(t, err)
+	return tx, tb
+}
+
+func TestTxInclusionProof(t *testing.T) {
+	block, tb := newTestBlock(t, 200)
+
+	for _, idx := range []uint{0, 1, 127, 199} {
+		proof, err := buildTxInclusionProof(tb.header, tb.txs, tb.receipts, idx)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, block.Hash(), proof.BlockHash)
+		assert.Equal(t, block.Transactions()[idx].Hash(), proof.TxHash)
+		assert.Equal(t, uint64(idx%2), proof.Status)
+
+		payload, err := rlp.EncodeToBytes(proof)
+		assert.NoError(t, err)
+		decoded, err := DecodeTxInclusionProof(payload)
+		assert.NoError(t, err)
+		assert.Equal(t, proof.BlockHash, decoded.BlockHash)
+	}
+}
+
+func TestTxInclusionProofTyped(t *testing.T) {
+	tx, tb := newTypedTestBlock(t)
+
+	enc, err := tx.encode()
+	assert.NoError(t, err)
+	assert.Equal(t, tb.txs[0], enc)
+
+	proof, err := buildTxInclusionProof(tb.header, tb.txs, tb.receipts, 0)
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash, proof.TxHash)
+	assert.Equal(t, uint64(1), proof.Status)
+	assert.Equal(t, uint64(15537394), proof.BlockNumber)
+
+	payload, err := rlp.EncodeToBytes(proof)
+	assert.NoError(t, err)
+	_, err = DecodeTxInclusionProof(payload)
+	assert.NoError(t, err)
+}
+
+func TestTxInclusionProofTampered(t *testing.T) {
+	_, tb := newTestBlock(t, 10)
+
+	proof, err := buildTxInclusionProof(tb.header, tb.txs, tb.receipts, 3)
+	require.NoError(t, err)
+
+	proof.Status = 0
+	assert.Error(t, proof.Verify())
+	proof.Status = 1
+
+	proof.TxHash = common.Hash{}
+	assert.Error(t, proof.Verify())
+	proof.TxHash = crypto.Keccak256Hash(tb.txs[3])
+	assert.NoError(t, proof.Verify())
+
+	// proofs of other block can't be attached to the header
+	_, other := newTestBlock(t, 11)
+	otherProof, err := buildTxInclusionProof(other.header, other.txs, other.receipts, 3)
+	require.NoError(t, err)
+	otherProof.Header = tb.header
+	assert.Error(t, otherProof.Verify())
+	otherProof.BlockHash = proof.BlockHash
+	assert.Error(t, otherProof.Verify())
+
+	_, err = buildTxInclusionProof(tb.header, tb.txs, tb.receipts, 10)
+	assert.Error(t, err)
+
+	// transactions must match the root of the header
+	_, err = buildTxInclusionProof(tb.header, tb.txs[:9], tb.receipts[:9], 3)
+	assert.Error(t, err)
+}
+
+func TestEncodeRPCBlock(t *testing.T) {
+	block, tb := newTestBlock(t, 3)
+
+	headerJSON, err := json.Marshal(block.Header())
+	require.NoError(t, err)
+	var rb rpcBlock
+	require.NoError(t, json.Unmarshal(headerJSON, &rb))
+	header, err := rb.encodeHeader()
+	assert.NoError(t, err)
+	assert.Equal(t, tb.header, header)
+
+	rb.GasUsed++
+	_, err = rb.encodeHeader()
+	assert.Error(t, err)
+
+	for i, tx := range block.Transactions() {
+		txJSON, err := tx.MarshalJSON()
+		require.NoError(t, err)
+		var rt rpcTransaction
+		require.NoError(t, json.Unmarshal(txJSON, &rt))
+		enc, err := rt.encode()
+		assert.NoError(t, err)
+		assert.Equal(t, tb.txs[i], enc)
+	}
+}
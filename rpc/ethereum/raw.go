@@ -0,0 +1,263 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/xerrors"
+)
+
+// Types of this go-ethereum version don't support typed transactions (EIP-2718) and header fields
+// added since London, so blocks, transactions and receipts needed for proofs are fetched as JSON
+// and encoded into their consensus form here. Every encoding is checked against the hash or root
+// it must match, so fields unknown to this code are detected instead of producing invalid proofs.
+
+const (
+	txTypeLegacy     = 0
+	txTypeAccessList = 1
+	txTypeDynamicFee = 2
+	txTypeBlob       = 3
+	txTypeSetCode    = 4
+)
+
+type rpcBlock struct {
+	Hash                  common.Hash       `json:"hash"`
+	ParentHash            common.Hash       `json:"parentHash"`
+	UncleHash             common.Hash       `json:"sha3Uncles"`
+	Coinbase              common.Address    `json:"miner"`
+	Root                  common.Hash       `json:"stateRoot"`
+	TxHash                common.Hash       `json:"transactionsRoot"`
+	ReceiptHash           common.Hash       `json:"receiptsRoot"`
+	Bloom                 hexutil.Bytes     `json:"logsBloom"`
+	Difficulty            *hexutil.Big      `json:"difficulty"`
+	Number                hexutil.Uint64    `json:"number"`
+	GasLimit              hexutil.Uint64    `json:"gasLimit"`
+	GasUsed               hexutil.Uint64    `json:"gasUsed"`
+	Time                  hexutil.Uint64    `json:"timestamp"`
+	Extra                 hexutil.Bytes     `json:"extraData"`
+	MixDigest             common.Hash       `json:"mixHash"`
+	Nonce                 hexutil.Bytes     `json:"nonce"`
+	BaseFee               *hexutil.Big      `json:"baseFeePerGas"`
+	WithdrawalsHash       *common.Hash      `json:"withdrawalsRoot"`
+	BlobGasUsed           *hexutil.Uint64   `json:"blobGasUsed"`
+	ExcessBlobGas         *hexutil.Uint64   `json:"excessBlobGas"`
+	ParentBeaconBlockRoot *common.Hash      `json:"parentBeaconBlockRoot"`
+	RequestsHash          *common.Hash      `json:"requestsHash"`
+	Transactions          []*rpcTransaction `json:"transactions"`
+}
+
+type rpcAccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+type rpcAuthorization struct {
+	ChainID *hexutil.Big   `json:"chainId"`
+	Address common.Address `json:"address"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	YParity hexutil.Uint64 `json:"yParity"`
+	R       *hexutil.Big   `json:"r"`
+	S       *hexutil.Big   `json:"s"`
+}
+
+type rpcTransaction struct {
+	Type                 hexutil.Uint64     `json:"type"`
+	Hash                 common.Hash        `json:"hash"`
+	ChainID              *hexutil.Big       `json:"chainId"`
+	Nonce                hexutil.Uint64     `json:"nonce"`
+	GasPrice             *hexutil.Big       `json:"gasPrice"`
+	MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas"`
+	Gas                  hexutil.Uint64     `json:"gas"`
+	To                   *common.Address    `json:"to"`
+	Value                *hexutil.Big       `json:"value"`
+	Input                hexutil.Bytes      `json:"input"`
+	AccessList           []rpcAccessTuple   `json:"accessList"`
+	MaxFeePerBlobGas     *hexutil.Big       `json:"maxFeePerBlobGas"`
+	BlobVersionedHashes  []common.Hash      `json:"blobVersionedHashes"`
+	AuthorizationList    []rpcAuthorization `json:"authorizationList"`
+	V                    *hexutil.Big       `json:"v"`
+	R                    *hexutil.Big       `json:"r"`
+	S                    *hexutil.Big       `json:"s"`
+}
+
+type rpcLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+type rpcReceipt struct {
+	Type              hexutil.Uint64  `json:"type"`
+	Root              hexutil.Bytes   `json:"root"`
+	Status            *hexutil.Uint64 `json:"status"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	Bloom             hexutil.Bytes   `json:"logsBloom"`
+	Logs              []rpcLog        `json:"logs"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+}
+
+func (erc *EthereumRPCClient) rawBlock(ctx context.Context, hash common.Hash) (*rpcBlock, error) {
+	var block *rpcBlock
+	if err := erc.rpc.CallContext(ctx, &block, "eth_getBlockByHash", hash, true); err != nil {
+		return nil, xerrors.Errorf("failed to get block %s: %w", hash.Hex(), err)
+	}
+	if block == nil {
+		return nil, xerrors.Errorf("block %s isn't found", hash.Hex())
+	}
+	return block, nil
+}
+
+func (erc *EthereumRPCClient) rawReceipt(ctx context.Context, txHash common.Hash) (*rpcReceipt, error) {
+	var receipt *rpcReceipt
+	if err := erc.rpc.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, xerrors.Errorf("failed to get receipt of transaction %s: %w", txHash.Hex(), err)
+	}
+	if receipt == nil {
+		return nil, xerrors.Errorf("receipt of transaction %s isn't found", txHash.Hex())
+	}
+	return receipt, nil
+}
+
+// encodeHeader returns RLP encoding of the block header, its hash must be the block hash
+func (b *rpcBlock) encodeHeader() ([]byte, error) {
+	fields := []interface{}{
+		b.ParentHash,
+		b.UncleHash,
+		b.Coinbase,
+		b.Root,
+		b.TxHash,
+		b.ReceiptHash,
+		[]byte(b.Bloom),
+		toBig(b.Difficulty),
+		uint64(b.Number),
+		uint64(b.GasLimit),
+		uint64(b.GasUsed),
+		uint64(b.Time),
+		[]byte(b.Extra),
+		b.MixDigest,
+		[]byte(b.Nonce),
+	}
+	// fields added by later forks are present only since their activation, every next one implies the previous
+	optional := []interface{}{}
+	if b.BaseFee != nil {
+		optional = append(optional, toBig(b.BaseFee))
+	}
+	if b.WithdrawalsHash != nil {
+		optional = append(optional, *b.WithdrawalsHash)
+	}
+	if b.BlobGasUsed != nil && b.ExcessBlobGas != nil {
+		optional = append(optional, uint64(*b.BlobGasUsed), uint64(*b.ExcessBlobGas))
+	}
+	if b.ParentBeaconBlockRoot != nil {
+		optional = append(optional, *b.ParentBeaconBlockRoot)
+	}
+	if b.RequestsHash != nil {
+		optional = append(optional, *b.RequestsHash)
+	}
+	header, err := rlp.EncodeToBytes(append(fields, optional...))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode header of block %s: %w", b.Hash.Hex(), err)
+	}
+	if crypto.Keccak256Hash(header) != b.Hash {
+		return nil, xerrors.Errorf("encoded header doesn't match hash of block %s", b.Hash.Hex())
+	}
+	return header, nil
+}
+
+// encode returns the consensus encoding of the transaction as it's stored in the transactions trie
+func (tx *rpcTransaction) encode() ([]byte, error) {
+	var to []byte
+	if tx.To != nil {
+		to = tx.To.Bytes()
+	}
+	accessList := make([]interface{}, 0, len(tx.AccessList))
+	for _, t := range tx.AccessList {
+		keys := t.StorageKeys
+		if keys == nil {
+			keys = []common.Hash{}
+		}
+		accessList = append(accessList, []interface{}{t.Address, keys})
+	}
+
+	var fields []interface{}
+	switch tx.Type {
+	case txTypeLegacy:
+		fields = []interface{}{uint64(tx.Nonce), toBig(tx.GasPrice), uint64(tx.Gas), to, toBig(tx.Value), []byte(tx.Input), toBig(tx.V), toBig(tx.R), toBig(tx.S)}
+	case txTypeAccessList:
+		fields = []interface{}{toBig(tx.ChainID), uint64(tx.Nonce), toBig(tx.GasPrice), uint64(tx.Gas), to, toBig(tx.Value), []byte(tx.Input), accessList, toBig(tx.V), toBig(tx.R), toBig(tx.S)}
+	case txTypeDynamicFee:
+		fields = []interface{}{toBig(tx.ChainID), uint64(tx.Nonce), toBig(tx.MaxPriorityFeePerGas), toBig(tx.MaxFeePerGas), uint64(tx.Gas), to, toBig(tx.Value), []byte(tx.Input), accessList, toBig(tx.V), toBig(tx.R), toBig(tx.S)}
+	case txTypeBlob:
+		hashes := tx.BlobVersionedHashes
+		if hashes == nil {
+			hashes = []common.Hash{}
+		}
+		fields = []interface{}{toBig(tx.ChainID), uint64(tx.Nonce), toBig(tx.MaxPriorityFeePerGas), toBig(tx.MaxFeePerGas), uint64(tx.Gas), to, toBig(tx.Value), []byte(tx.Input), accessList, toBig(tx.MaxFeePerBlobGas), hashes, toBig(tx.V), toBig(tx.R), toBig(tx.S)}
+	case txTypeSetCode:
+		auths := make([]interface{}, 0, len(tx.AuthorizationList))
+		for _, a := range tx.AuthorizationList {
+			auths = append(auths, []interface{}{toBig(a.ChainID), a.Address, uint64(a.Nonce), uint64(a.YParity), toBig(a.R), toBig(a.S)})
+		}
+		fields = []interface{}{toBig(tx.ChainID), uint64(tx.Nonce), toBig(tx.MaxPriorityFeePerGas), toBig(tx.MaxFeePerGas), uint64(tx.Gas), to, toBig(tx.Value), []byte(tx.Input), accessList, auths, toBig(tx.V), toBig(tx.R), toBig(tx.S)}
+	default:
+		return nil, xerrors.Errorf("transaction %s has unsupported type %d", tx.Hash.Hex(), tx.Type)
+	}
+
+	enc, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode transaction %s: %w", tx.Hash.Hex(), err)
+	}
+	if tx.Type != txTypeLegacy {
+		enc = append([]byte{byte(tx.Type)}, enc...)
+	}
+	if crypto.Keccak256Hash(enc) != tx.Hash {
+		return nil, xerrors.Errorf("encoded transaction doesn't match its hash %s", tx.Hash.Hex())
+	}
+	return enc, nil
+}
+
+// encode returns the consensus encoding of the receipt as it's stored in the receipts trie
+func (r *rpcReceipt) encode() ([]byte, error) {
+	var statusOrRoot []byte
+	switch {
+	case len(r.Root) != 0:
+		statusOrRoot = r.Root
+	case r.Status == nil:
+		return nil, xerrors.Errorf("receipt has neither status nor state root")
+	case *r.Status == 1:
+		statusOrRoot = []byte{1}
+	default:
+		statusOrRoot = []byte{}
+	}
+	logs := make([]interface{}, 0, len(r.Logs))
+	for _, l := range r.Logs {
+		topics := l.Topics
+		if topics == nil {
+			topics = []common.Hash{}
+		}
+		logs = append(logs, []interface{}{l.Address, topics, []byte(l.Data)})
+	}
+
+	enc, err := rlp.EncodeToBytes([]interface{}{statusOrRoot, uint64(r.CumulativeGasUsed), []byte(r.Bloom), logs})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode receipt: %w", err)
+	}
+	if r.Type != txTypeLegacy {
+		enc = append([]byte{byte(r.Type)}, enc...)
+	}
+	return enc, nil
+}
+
+func toBig(b *hexutil.Big) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return b.ToInt()
+}
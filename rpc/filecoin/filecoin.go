@@ -2,36 +2,150 @@ package filecoin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Secured-Finance/dione/rpc"
 	ftypes "github.com/Secured-Finance/dione/rpc/filecoin/types"
 	"github.com/Secured-Finance/dione/rpc/types"
 	"github.com/filecoin-project/go-address"
 
 	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
+	"golang.org/x/xerrors"
 )
 
 var filecoinURL = "https://api.node.glif.io/"
 
+const (
+	DefaultHealthCheckInterval = 30 * time.Second
+	requestTimeout             = 30 * time.Second
+)
+
+type lotusEndpoint struct {
+	url     string
+	healthy bool
+}
+
 // client implements the `Client` interface.
+// Requests are balanced across configured Lotus endpoints, unhealthy ones are taken out of rotation
+// until health check succeeds again.
 type LotusClient struct {
-	host       string
-	httpClient *fasthttp.Client
+	endpoints     []*lotusEndpoint
+	token         string
+	confirmations int64
+	next          uint32
+	mutex         sync.RWMutex
+	httpClient    *fasthttp.Client
 }
 
 // NewClient returns a new client.
-func NewLotusClient() *LotusClient {
-	return &LotusClient{
-		host:       filecoinURL,
-		httpClient: &fasthttp.Client{},
+// The client answers only about objects included at least confirmations epochs deep in the chain.
+func NewLotusClient(hosts []string, token string, transport *rpc.Transport, confirmations int64) *LotusClient {
+	if len(hosts) == 0 {
+		hosts = []string{filecoinURL}
+	}
+	c := &LotusClient{
+		token:         token,
+		confirmations: confirmations,
+		httpClient:    transport.FasthttpClient(),
+	}
+	for _, h := range hosts {
+		c.endpoints = append(c.endpoints, &lotusEndpoint{url: h, healthy: true})
+	}
+	return c
+}
+
+// RunHealthChecks pings every endpoint with Filecoin.Version periodically, it blocks until ctx is done
+func (c *LotusClient) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, e := range c.endpoints {
+				_, err := c.doRequest(e.url, "Filecoin.Version", nil)
+				c.setHealthy(e, err == nil)
+			}
+		}
+	}
+}
+
+// HealthyEndpoints returns URLs of endpoints which are currently in rotation
+func (c *LotusClient) HealthyEndpoints() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var res []string
+	for _, e := range c.endpoints {
+		if e.healthy {
+			res = append(res, e.url)
+		}
+	}
+	return res
+}
+
+func (c *LotusClient) setHealthy(e *lotusEndpoint, healthy bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e.healthy == healthy {
+		return
 	}
+	e.healthy = healthy
+	if healthy {
+		logrus.Infof("Lotus endpoint %s is back in rotation", e.url)
+	} else {
+		logrus.Warnf("Lotus endpoint %s is unhealthy, removing it from rotation", e.url)
+	}
+}
+
+// rotation returns endpoints in order they should be tried: healthy ones starting from the next in round-robin,
+// or all of them if none is healthy
+func (c *LotusClient) rotation() []*lotusEndpoint {
+	start := int(atomic.AddUint32(&c.next, 1)-1) % len(c.endpoints)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var healthy []*lotusEndpoint
+	for i := range c.endpoints {
+		e := c.endpoints[(start+i)%len(c.endpoints)]
+		if e.healthy {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		return c.endpoints
+	}
+	return healthy
 }
 
 func (c *LotusClient) GetBlock(cid string) ([]byte, error) {
 	i := ftypes.NewCidParam(cid)
-	return c.HandleRequest("Filecoin.ChainGetBlock", i)
+	body, err := c.HandleRequest("Filecoin.ChainGetBlock", i)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Height int64
+	}
+	if err := unmarshalResult(body, &header); err != nil {
+		return nil, xerrors.Errorf("failed to decode block header: %w", err)
+	}
+	if err := c.checkConfirmations(header.Height); err != nil {
+		return nil, err
+	}
+	return body, nil
 }
 
 func (c *LotusClient) GetTipSetByHeight(chainEpoch int64) ([]byte, error) {
@@ -62,6 +176,21 @@ func (c *LotusClient) VerifyCid(cid string) ([]byte, error) {
 // Gets signed transaction from Filecoin and returns SignedTransaction struct in byte slice
 func (c *LotusClient) GetTransaction(cid string) ([]byte, error) {
 	i := ftypes.NewCidParam(cid)
+	if c.confirmations > 0 {
+		lookupBody, err := c.HandleRequest("Filecoin.StateSearchMsg", i)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to search message: %w", err)
+		}
+		var lookup struct {
+			Height int64
+		}
+		if err := unmarshalResult(lookupBody, &lookup); err != nil {
+			return nil, xerrors.Errorf("message %s isn't included on chain: %w", cid, err)
+		}
+		if err := c.checkConfirmations(lookup.Height); err != nil {
+			return nil, err
+		}
+	}
 	bodyBytes, err := c.HandleRequest("Filecoin.ChainReadObj", i)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get object information %v", err)
@@ -95,12 +224,122 @@ func (c *LotusClient) GetTransaction(cid string) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// GetStorageDeal returns state of the storage deal as JSON-encoded DealStatus.
+// Params format: "<deal id>:<epoch>", state is read at the tipset of specified epoch,
+// so every miner gets the same answer regardless of its view of chain head.
+func (c *LotusClient) GetStorageDeal(params string) ([]byte, error) {
+	p := strings.Split(params, ":")
+	if len(p) != 2 {
+		return nil, xerrors.Errorf("invalid params format, expected <deal id>:<epoch>")
+	}
+	dealID, err := strconv.ParseUint(p[0], 10, 64)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid deal id: %w", err)
+	}
+	epoch, err := strconv.ParseInt(p[1], 10, 64)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid epoch: %w", err)
+	}
+
+	tsBody, err := c.GetTipSetByHeight(epoch)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get tipset at epoch %d: %w", epoch, err)
+	}
+	var ts ftypes.TipSet
+	if err := unmarshalResult(tsBody, &ts); err != nil {
+		return nil, xerrors.Errorf("failed to decode tipset: %w", err)
+	}
+	if err := c.checkConfirmations(ts.Height); err != nil {
+		return nil, err
+	}
+
+	dealBody, err := c.HandleRequest("Filecoin.StateMarketStorageDeal", []interface{}{dealID, ts.Cids})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get storage deal: %w", err)
+	}
+	var deal ftypes.MarketDeal
+	if err := unmarshalResult(dealBody, &deal); err != nil {
+		return nil, xerrors.Errorf("failed to decode storage deal: %w", err)
+	}
+
+	pieceCID, _ := deal.Proposal.PieceCID.Cid.(string)
+	status := ftypes.DealStatus{
+		DealID:          dealID,
+		Epoch:           ts.Height,
+		Client:          deal.Proposal.Client,
+		Provider:        deal.Proposal.Provider,
+		PieceCID:        pieceCID,
+		StartEpoch:      deal.Proposal.StartEpoch,
+		EndEpoch:        deal.Proposal.EndEpoch,
+		ActivationEpoch: deal.State.SectorStartEpoch,
+		SlashEpoch:      deal.State.SlashEpoch,
+		Active:          deal.State.SectorStartEpoch >= 0 && deal.State.SlashEpoch < 0,
+		Slashed:         deal.State.SlashEpoch >= 0,
+	}
+	return json.Marshal(status)
+}
+
+// checkConfirmations ensures that the epoch is deep enough in the chain, so the answer can't be reverted by reorg
+func (c *LotusClient) checkConfirmations(epoch int64) error {
+	if c.confirmations <= 0 {
+		return nil
+	}
+	body, err := c.GetChainHead()
+	if err != nil {
+		return xerrors.Errorf("failed to get chain head: %w", err)
+	}
+	var head ftypes.TipSet
+	if err := unmarshalResult(body, &head); err != nil {
+		return xerrors.Errorf("failed to decode chain head: %w", err)
+	}
+	if epoch > head.Height || head.Height-epoch < c.confirmations {
+		return xerrors.Errorf("epoch %d doesn't have required %d confirmations yet (head is %d)", epoch, c.confirmations, head.Height)
+	}
+	return nil
+}
+
+// unmarshalResult decodes result of JSON-RPC reply into v, returning RPC error if there is one
+func unmarshalResult(body []byte, v interface{}) error {
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *types.Error    `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return xerrors.Errorf("rpc error %d: %s", response.Error.Code, response.Error.Message)
+	}
+	if len(response.Result) == 0 || string(response.Result) == "null" {
+		return xerrors.Errorf("empty result")
+	}
+	return json.Unmarshal(response.Result, v)
+}
+
 // HandleRequest implements the `Client` interface.
 func (c *LotusClient) HandleRequest(method string, params []interface{}) ([]byte, error) {
+	var err error
+	for _, e := range c.rotation() {
+		var body []byte
+		body, err = c.doRequest(e.url, method, params)
+		if err == nil {
+			return body, nil
+		}
+		logrus.Warnf("Filecoin node rpc request to %s failed: %v", e.url, err)
+		c.setHealthy(e, false)
+	}
+	return nil, err
+}
+
+func (c *LotusClient) doRequest(url, method string, params []interface{}) ([]byte, error) {
 	req := fasthttp.AcquireRequest()
-	req.SetRequestURI(c.host)
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI(url)
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 	requestBody := types.NewRPCRequestBody(method)
 	requestBody.Params = append(requestBody.Params, params...)
 	body, err := json.Marshal(requestBody)
@@ -109,11 +348,14 @@ func (c *LotusClient) HandleRequest(method string, params []interface{}) ([]byte
 	}
 	req.AppendBody(body)
 	resp := fasthttp.AcquireResponse()
-	if err = c.httpClient.Do(req, resp); err != nil {
-		logrus.Warn("Failed to construct filecoin node rpc request", err)
+	defer fasthttp.ReleaseResponse(resp)
+	if err = c.httpClient.DoTimeout(req, resp, requestTimeout); err != nil {
 		return nil, err
 	}
-	bodyBytes := resp.Body()
+	if resp.StatusCode() >= fasthttp.StatusInternalServerError {
+		return nil, xerrors.Errorf("filecoin node replied with status %d", resp.StatusCode())
+	}
+	bodyBytes := append([]byte(nil), resp.Body()...)
 	logrus.Debugf("Filecoin RPC reply: %v", string(bodyBytes))
 	return bodyBytes, nil
 }
@@ -0,0 +1,31 @@
+package filecoin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLotusClientFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","result":{"Version":"1.2.3"},"id":0}`))
+	}))
+	defer up.Close()
+
+	c := NewLotusClient([]string{down.URL, up.URL}, "", nil, 0)
+	for i := 0; i < 3; i++ {
+		_, err := c.GetNodeVersion()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{up.URL}, c.HealthyEndpoints())
+
+	up.Close()
+	_, err := c.GetNodeVersion()
+	assert.Error(t, err)
+}
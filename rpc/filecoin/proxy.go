@@ -0,0 +1,279 @@
+package filecoin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultProxyCacheTTL = 5 * time.Second
+	maxProxyCacheEntries = 10000
+	quotaWindow          = time.Minute
+	metricsPath          = "/metrics"
+)
+
+// MethodStats contains counters of requests served by the proxy for single Lotus method
+type MethodStats struct {
+	Requests  uint64 `json:"requests"`
+	CacheHits uint64 `json:"cache_hits"`
+	Errors    uint64 `json:"errors"`
+}
+
+type proxyRequest struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  []interface{}   `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type cachedResponse struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+type callerQuota struct {
+	windowStart time.Time
+	count       int
+}
+
+// LotusProxy shares single Lotus connection with other components or trusted clients.
+// Callers are authenticated by bearer tokens, only allowlisted methods are forwarded
+// and responses are cached for a short time.
+type LotusProxy struct {
+	client         *LotusClient
+	listenAddr     string
+	allowedMethods map[string]struct{}
+	callers        map[string]string // token -> caller name
+	cacheTTL       time.Duration
+	quota          int
+
+	mutex   sync.Mutex
+	cache   map[string]*cachedResponse
+	quotas  map[string]*callerQuota
+	metrics map[string]*MethodStats
+}
+
+func NewLotusProxy(client *LotusClient, cfg *config.LotusProxyConfig) (*LotusProxy, error) {
+	if len(cfg.AllowedMethods) == 0 {
+		return nil, xerrors.Errorf("lotus proxy requires at least one allowed method")
+	}
+	if len(cfg.Tokens) == 0 {
+		return nil, xerrors.Errorf("lotus proxy requires at least one caller token")
+	}
+
+	p := &LotusProxy{
+		client:         client,
+		listenAddr:     cfg.ListenAddr,
+		allowedMethods: map[string]struct{}{},
+		callers:        map[string]string{},
+		cacheTTL:       time.Duration(cfg.CacheTTL) * time.Second,
+		quota:          cfg.QuotaPerMinute,
+		cache:          map[string]*cachedResponse{},
+		quotas:         map[string]*callerQuota{},
+		metrics:        map[string]*MethodStats{},
+	}
+	if p.cacheTTL <= 0 {
+		p.cacheTTL = DefaultProxyCacheTTL
+	}
+	for _, m := range cfg.AllowedMethods {
+		p.allowedMethods[m] = struct{}{}
+	}
+	for caller, token := range cfg.Tokens {
+		p.callers[token] = caller
+	}
+
+	return p, nil
+}
+
+// Serve starts serving proxy requests, it blocks until ctx is done
+func (p *LotusProxy) Serve(ctx context.Context) error {
+	srv := &fasthttp.Server{Handler: p.handle}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(); err != nil {
+			logrus.Errorf("Failed to shutdown lotus proxy: %v", err)
+		}
+	}()
+	logrus.Infof("Lotus proxy is listening on %s", p.listenAddr)
+	return srv.ListenAndServe(p.listenAddr)
+}
+
+// Metrics returns a snapshot of per-method request counters
+func (p *LotusProxy) Metrics() map[string]MethodStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	res := make(map[string]MethodStats, len(p.metrics))
+	for m, s := range p.metrics {
+		res[m] = *s
+	}
+	return res
+}
+
+func (p *LotusProxy) handle(ctx *fasthttp.RequestCtx) {
+	caller, ok := p.authenticate(ctx)
+	if !ok {
+		ctx.Error("unauthorized", fasthttp.StatusUnauthorized)
+		return
+	}
+
+	if ctx.IsGet() && string(ctx.Path()) == metricsPath {
+		body, _ := json.Marshal(p.Metrics())
+		ctx.SetContentType("application/json")
+		ctx.SetBody(body)
+		return
+	}
+	if !ctx.IsPost() {
+		ctx.Error("method not allowed", fasthttp.StatusMethodNotAllowed)
+		return
+	}
+
+	var req proxyRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.Error("malformed json-rpc request", fasthttp.StatusBadRequest)
+		return
+	}
+	if _, ok := p.allowedMethods[req.Method]; !ok {
+		ctx.Error("method is not allowed", fasthttp.StatusForbidden)
+		return
+	}
+	if !p.takeQuota(caller) {
+		ctx.Error("quota exceeded", fasthttp.StatusTooManyRequests)
+		return
+	}
+
+	body, err := p.forward(&req)
+	if err != nil {
+		logrus.Warnf("Lotus proxy failed to forward %s request of %s: %v", req.Method, caller, err)
+		ctx.Error("lotus request failed", fasthttp.StatusBadGateway)
+		return
+	}
+	body, err = replaceResponseID(body, req.ID)
+	if err != nil {
+		ctx.Error("malformed lotus response", fasthttp.StatusBadGateway)
+		return
+	}
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+func (p *LotusProxy) authenticate(ctx *fasthttp.RequestCtx) (string, bool) {
+	auth := string(ctx.Request.Header.Peek("Authorization"))
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if token == "" || token == auth {
+		return "", false
+	}
+	// every token is compared in constant time, so timing doesn't tell how much of the token matches
+	caller, ok := "", false
+	for t, c := range p.callers {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			caller, ok = c, true
+		}
+	}
+	return caller, ok
+}
+
+func (p *LotusProxy) takeQuota(caller string) bool {
+	if p.quota <= 0 {
+		return true
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	q, ok := p.quotas[caller]
+	if !ok || time.Since(q.windowStart) >= quotaWindow {
+		q = &callerQuota{windowStart: time.Now()}
+		p.quotas[caller] = q
+	}
+	if q.count >= p.quota {
+		return false
+	}
+	q.count++
+	return true
+}
+
+func (p *LotusProxy) forward(req *proxyRequest) ([]byte, error) {
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, err
+	}
+	key := req.Method + string(params)
+
+	p.mutex.Lock()
+	stats, ok := p.metrics[req.Method]
+	if !ok {
+		stats = &MethodStats{}
+		p.metrics[req.Method] = stats
+	}
+	stats.Requests++
+	if c, ok := p.cache[key]; ok && time.Now().Before(c.expiresAt) {
+		stats.CacheHits++
+		p.mutex.Unlock()
+		return c.body, nil
+	}
+	p.mutex.Unlock()
+
+	body, err := p.client.HandleRequest(req.Method, req.Params)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err != nil {
+		stats.Errors++
+		return nil, err
+	}
+	// errors may be transient, so they aren't served from cache
+	if isErrorResponse(body) {
+		stats.Errors++
+		return body, nil
+	}
+	if len(p.cache) >= maxProxyCacheEntries {
+		p.pruneCache()
+	}
+	p.cache[key] = &cachedResponse{body: body, expiresAt: time.Now().Add(p.cacheTTL)}
+	return body, nil
+}
+
+func (p *LotusProxy) pruneCache() {
+	now := time.Now()
+	for k, c := range p.cache {
+		if now.After(c.expiresAt) {
+			delete(p.cache, k)
+		}
+	}
+	if len(p.cache) >= maxProxyCacheEntries {
+		p.cache = map[string]*cachedResponse{}
+	}
+}
+
+// isErrorResponse reports whether the json-rpc response has an error member
+func isErrorResponse(body []byte) bool {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return true
+	}
+	return len(resp.Error) != 0 && string(resp.Error) != "null"
+}
+
+// replaceResponseID sets ID of the caller's request into the response, since Lotus replies to proxy's own request ID
+func replaceResponseID(body []byte, id json.RawMessage) ([]byte, error) {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	resp["id"] = id
+	return json.Marshal(resp)
+}
@@ -0,0 +1,91 @@
+package filecoin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func newTestProxy(t *testing.T, upstreamCalls *int32) (*LotusProxy, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(upstreamCalls, 1)
+		var req proxyRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Method == "Filecoin.ChainHead" {
+			w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":1,"message":"not synced"},"id":0}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","result":{"Version":"1.2.3"},"id":0}`))
+	}))
+
+	client := NewLotusClient([]string{srv.URL}, "", nil, 0)
+	p, err := NewLotusProxy(client, &config.LotusProxyConfig{
+		AllowedMethods: []string{"Filecoin.Version", "Filecoin.ChainHead"},
+		Tokens:         map[string]string{"indexer": "secret", "explorer": "other secret"},
+		QuotaPerMinute: 2,
+	})
+	assert.NoError(t, err)
+	return p, srv.Close
+}
+
+func doProxyRequest(p *LotusProxy, token, body string) *fasthttp.RequestCtx {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.SetRequestURI("/rpc/v0")
+	if token != "" {
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	ctx.Request.SetBodyString(body)
+	p.handle(&ctx)
+	return &ctx
+}
+
+func TestLotusProxy(t *testing.T) {
+	var upstreamCalls int32
+	p, closeUpstream := newTestProxy(t, &upstreamCalls)
+	defer closeUpstream()
+
+	versionReq := `{"jsonrpc":"2.0","method":"Filecoin.Version","params":[],"id":42}`
+
+	ctx := doProxyRequest(p, "", versionReq)
+	assert.Equal(t, fasthttp.StatusUnauthorized, ctx.Response.StatusCode())
+	ctx = doProxyRequest(p, "secre", versionReq)
+	assert.Equal(t, fasthttp.StatusUnauthorized, ctx.Response.StatusCode())
+
+	ctx = doProxyRequest(p, "secret", `{"jsonrpc":"2.0","method":"Filecoin.WalletSign","params":[],"id":1}`)
+	assert.Equal(t, fasthttp.StatusForbidden, ctx.Response.StatusCode())
+
+	for i := 0; i < 2; i++ {
+		ctx = doProxyRequest(p, "secret", versionReq)
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+		var resp map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(ctx.Response.Body(), &resp))
+		assert.Equal(t, "42", string(resp["id"]))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&upstreamCalls))
+	assert.Equal(t, MethodStats{Requests: 2, CacheHits: 1}, p.Metrics()["Filecoin.Version"])
+
+	ctx = doProxyRequest(p, "secret", versionReq)
+	assert.Equal(t, fasthttp.StatusTooManyRequests, ctx.Response.StatusCode())
+}
+
+func TestLotusProxyErrorsNotCached(t *testing.T) {
+	var upstreamCalls int32
+	p, closeUpstream := newTestProxy(t, &upstreamCalls)
+	defer closeUpstream()
+
+	headReq := `{"jsonrpc":"2.0","method":"Filecoin.ChainHead","params":[],"id":7}`
+	for i := 0; i < 2; i++ {
+		ctx := doProxyRequest(p, "other secret", headReq)
+		assert.Equal(t, fasthttp.StatusOK, ctx.Response.StatusCode())
+		assert.Contains(t, string(ctx.Response.Body()), "not synced")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&upstreamCalls))
+	assert.Equal(t, MethodStats{Requests: 2, Errors: 2}, p.Metrics()["Filecoin.ChainHead"])
+}
@@ -0,0 +1,44 @@
+package types
+
+// MarketDeal is a storage deal as returned by Filecoin.StateMarketStorageDeal
+type MarketDeal struct {
+	Proposal DealProposal
+	State    DealState
+}
+
+type DealProposal struct {
+	PieceCID     FilParams
+	PieceSize    uint64
+	VerifiedDeal bool
+	Client       string
+	Provider     string
+	StartEpoch   int64
+	EndEpoch     int64
+}
+
+type DealState struct {
+	SectorStartEpoch int64 // -1 if not yet included in proven sector
+	LastUpdatedEpoch int64 // -1 if deal state never updated
+	SlashEpoch       int64 // -1 if deal never slashed
+}
+
+// DealStatus is the canonical representation of storage deal state returned as task payload
+type DealStatus struct {
+	DealID          uint64 `json:"deal_id"`
+	Epoch           int64  `json:"epoch"`
+	Client          string `json:"client"`
+	Provider        string `json:"provider"`
+	PieceCID        string `json:"piece_cid"`
+	StartEpoch      int64  `json:"start_epoch"`
+	EndEpoch        int64  `json:"end_epoch"`
+	ActivationEpoch int64  `json:"activation_epoch"`
+	SlashEpoch      int64  `json:"slash_epoch"`
+	Active          bool   `json:"active"`
+	Slashed         bool   `json:"slashed"`
+}
+
+// TipSet is a part of Filecoin tipset representation containing only fields used by Dione
+type TipSet struct {
+	Cids   []FilParams
+	Height int64
+}
@@ -1,5 +1,7 @@
 package rpc
 
+import "github.com/Secured-Finance/dione/reorg"
+
 var rpcs = map[uint8]map[string]func(string) ([]byte, error){} // rpcType -> {rpcMethodName -> actual func var}
 
 func RegisterRPC(rpcType uint8, rpcMethods map[string]func(string) ([]byte, error)) {
@@ -17,3 +19,23 @@ func GetRPCMethod(rpcType uint8, rpcMethodName string) func(string) ([]byte, err
 	}
 	return actualMethod
 }
+
+var anchoredRPCs = map[uint8]map[string]func(string) ([]byte, *reorg.Anchor, error){} // rpcType -> {rpcMethodName -> actual func var}
+
+// RegisterAnchoredRPC registers methods which also return the source chain block their answer is based on,
+// so the answer can be watched for reorgs of exactly that block
+func RegisterAnchoredRPC(rpcType uint8, rpcMethods map[string]func(string) ([]byte, *reorg.Anchor, error)) {
+	anchoredRPCs[rpcType] = rpcMethods
+}
+
+func GetAnchoredRPCMethod(rpcType uint8, rpcMethodName string) func(string) ([]byte, *reorg.Anchor, error) {
+	rpcMethods, ok := anchoredRPCs[rpcType]
+	if !ok {
+		return nil
+	}
+	actualMethod, ok := rpcMethods[rpcMethodName]
+	if !ok {
+		return nil
+	}
+	return actualMethod
+}
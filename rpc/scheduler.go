@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	rtypes "github.com/Secured-Finance/dione/rpc/types"
+	"golang.org/x/xerrors"
+)
+
+// chainNames are names of origin chains used in keys of fetch limits
+var chainNames = map[uint8]string{
+	rtypes.RPCTypeEthereum: "ethereum",
+	rtypes.RPCTypeFilecoin: "filecoin",
+	rtypes.RPCTypeSolana:   "solana",
+	rtypes.RPCTypeDrand:    "drand",
+}
+
+// Scheduler enforces concurrency and rate limits of data source requests. Limits are set per origin chain
+// (e.g. "filecoin") and per request type (e.g. "filecoin/getblock"), a request must pass both.
+// Queued requests are started in order of their deadlines, so requests closer to expiration go first.
+type Scheduler struct {
+	limiters map[string]*limiter
+}
+
+func NewScheduler(limits map[string]config.FetchLimitConfig) (*Scheduler, error) {
+	s := &Scheduler{limiters: map[string]*limiter{}}
+	for key, l := range limits {
+		key = strings.ToLower(key)
+		chain := strings.SplitN(key, "/", 2)[0]
+		if !isChainName(chain) {
+			return nil, xerrors.Errorf("fetch limit %q refers to unknown origin chain", key)
+		}
+		if l.MaxConcurrent < 0 || l.Rate < 0 {
+			return nil, xerrors.Errorf("fetch limit %q must not be negative", key)
+		}
+		s.limiters[key] = newLimiter(l.MaxConcurrent, l.Rate)
+	}
+	return s, nil
+}
+
+// Do calls fetch once the limits of the origin chain and request type allow it. Deadline of ctx
+// is the priority of the request in queue, requests without deadline are started last.
+func (s *Scheduler) Do(ctx context.Context, originChain uint8, requestType string, fetch func() ([]byte, error)) ([]byte, error) {
+	if s == nil {
+		return fetch()
+	}
+	chain := chainNames[originChain]
+	for _, key := range []string{chain, chain + "/" + strings.ToLower(requestType)} {
+		l, ok := s.limiters[key]
+		if !ok {
+			continue
+		}
+		if err := l.acquire(ctx); err != nil {
+			return nil, xerrors.Errorf("request wasn't scheduled by %s limit: %w", key, err)
+		}
+		defer l.release()
+	}
+	return fetch()
+}
+
+func isChainName(name string) bool {
+	for _, n := range chainNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type waiter struct {
+	deadline time.Time
+	seq      uint64
+	start    time.Time // reserved start time, set when the waiter is granted
+	granted  chan struct{}
+	index    int
+}
+
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	di, dj := q[i].deadline, q[j].deadline
+	if di.IsZero() != dj.IsZero() {
+		return !di.IsZero()
+	}
+	if !di.Equal(dj) {
+		return di.Before(dj)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// limiter is a semaphore with minimal interval between starts of requests
+type limiter struct {
+	mutex         sync.Mutex
+	maxConcurrent int           // 0 means unlimited
+	interval      time.Duration // 0 means unlimited rate
+	running       int
+	nextStart     time.Time
+	seq           uint64
+	queue         waitQueue
+}
+
+func newLimiter(maxConcurrent int, rate float64) *limiter {
+	l := &limiter{maxConcurrent: maxConcurrent}
+	if rate > 0 {
+		l.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return l
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	l.mutex.Lock()
+	if l.queue.Len() == 0 && l.hasCapacity() {
+		l.running++
+		start := l.reserveStart()
+		l.mutex.Unlock()
+		return l.waitStart(ctx, start)
+	}
+	deadline, _ := ctx.Deadline()
+	l.seq++
+	w := &waiter{deadline: deadline, seq: l.seq, granted: make(chan struct{})}
+	heap.Push(&l.queue, w)
+	l.mutex.Unlock()
+
+	select {
+	case <-w.granted:
+		return l.waitStart(ctx, w.start)
+	case <-ctx.Done():
+		l.mutex.Lock()
+		if w.index >= 0 {
+			heap.Remove(&l.queue, w.index)
+			l.mutex.Unlock()
+			return ctx.Err()
+		}
+		l.mutex.Unlock()
+		// the waiter has been granted concurrently
+		l.release()
+		return ctx.Err()
+	}
+}
+
+// waitStart sleeps until the reserved start time, the slot is given back if ctx is done
+func (l *limiter) waitStart(ctx context.Context, start time.Time) error {
+	d := time.Until(start)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.release()
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.running--
+	for l.queue.Len() > 0 && l.hasCapacity() {
+		w := heap.Pop(&l.queue).(*waiter)
+		l.running++
+		w.start = l.reserveStart()
+		close(w.granted)
+	}
+}
+
+func (l *limiter) hasCapacity() bool {
+	return l.maxConcurrent <= 0 || l.running < l.maxConcurrent
+}
+
+// reserveStart returns the earliest start time allowed by the rate and moves it forward
+func (l *limiter) reserveStart() time.Time {
+	now := time.Now()
+	if l.interval == 0 {
+		return now
+	}
+	if l.nextStart.Before(now) {
+		l.nextStart = now
+	}
+	start := l.nextStart
+	l.nextStart = start.Add(l.interval)
+	return start
+}
@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	rtypes "github.com/Secured-Finance/dione/rpc/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerConcurrency(t *testing.T) {
+	s, err := NewScheduler(map[string]config.FetchLimitConfig{
+		"Filecoin": {MaxConcurrent: 2},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Do(context.Background(), rtypes.RPCTypeFilecoin, "getBlock", func() ([]byte, error) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil, nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxRunning)
+
+	// other chains aren't limited
+	_, err = s.Do(context.Background(), rtypes.RPCTypeEthereum, "getTransaction", func() ([]byte, error) { return nil, nil })
+	assert.NoError(t, err)
+
+	_, err = NewScheduler(map[string]config.FetchLimitConfig{"bitcoin": {MaxConcurrent: 1}})
+	assert.Error(t, err)
+}
+
+func TestSchedulerRate(t *testing.T) {
+	s, err := NewScheduler(map[string]config.FetchLimitConfig{
+		"ethereum/gettransaction": {Rate: 20},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := s.Do(context.Background(), rtypes.RPCTypeEthereum, "getTransaction", func() ([]byte, error) { return nil, nil })
+		assert.NoError(t, err)
+	}
+	// the first request starts immediately, others are spaced by 50ms
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestSchedulerDeadlinePriority(t *testing.T) {
+	s, err := NewScheduler(map[string]config.FetchLimitConfig{"drand": {MaxConcurrent: 1}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	release := make(chan struct{})
+	go s.Do(context.Background(), rtypes.RPCTypeDrand, "getRandomness", func() ([]byte, error) {
+		<-release
+		return nil, nil
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	var mutex sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(name string, timeout time.Duration) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := context.Background()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			_, err := s.Do(ctx, rtypes.RPCTypeDrand, "getRandomness", func() ([]byte, error) {
+				mutex.Lock()
+				order = append(order, name)
+				mutex.Unlock()
+				return nil, nil
+			})
+			assert.NoError(t, err)
+		}()
+		time.Sleep(10 * time.Millisecond)
+	}
+	enqueue("no deadline", 0)
+	enqueue("late", time.Hour)
+	enqueue("soon", time.Minute)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []string{"soon", "late", "no deadline"}, order)
+
+	// queued request gives up when its context is done
+	release = make(chan struct{})
+	go s.Do(context.Background(), rtypes.RPCTypeDrand, "getRandomness", func() ([]byte, error) {
+		<-release
+		return nil, nil
+	})
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = s.Do(ctx, rtypes.RPCTypeDrand, "getRandomness", func() ([]byte, error) { return nil, nil })
+	assert.Error(t, err)
+	close(release)
+
+	_, err = s.Do(context.Background(), rtypes.RPCTypeDrand, "getRandomness", func() ([]byte, error) { return nil, nil })
+	assert.NoError(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/Secured-Finance/dione/rpc"
 	"github.com/Secured-Finance/dione/rpc/types"
 
 	stypes "github.com/Secured-Finance/dione/rpc/solana/types"
@@ -17,8 +18,9 @@ import (
 var solanaAlphabet = base58.BitcoinAlphabet
 
 type SolanaClient struct {
-	url string
-	ws  string
+	url        string
+	ws         string
+	httpClient *fasthttp.Client
 }
 
 type SubParams struct {
@@ -32,10 +34,11 @@ func NewSubParam(encoding string) *SubParams {
 }
 
 // NewSolanaClient creates a new solana client structure.
-func NewSolanaClient() *SolanaClient {
+func NewSolanaClient(transport *rpc.Transport) *SolanaClient {
 	return &SolanaClient{
-		url: "http://devnet.solana.com:8899/",
-		ws:  "ws://devnet.solana.com:8900/",
+		url:        "http://devnet.solana.com:8899/",
+		ws:         "ws://devnet.solana.com:8900/",
+		httpClient: transport.FasthttpClient(),
 	}
 }
 
@@ -52,8 +55,7 @@ func (c *SolanaClient) GetTransaction(txHash string) ([]byte, error) {
 	}
 	req.AppendBody(body)
 	resp := fasthttp.AcquireResponse()
-	client := &fasthttp.Client{}
-	if err = client.Do(req, resp); err != nil {
+	if err = c.httpClient.Do(req, resp); err != nil {
 		logrus.Warn("Failed to construct solana node rpc request", err)
 		return nil, err
 	}
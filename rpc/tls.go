@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/Secured-Finance/dione/config"
+	"golang.org/x/xerrors"
+)
+
+// NewTLSConfig builds TLS configuration for outbound connections to the data source.
+// It returns nil if no TLS options are configured, so default system settings are used.
+func NewTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" && cfg.KeyFile == "" && cfg.CAFile == "" && cfg.ServerName == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, xerrors.Errorf("CA bundle %s doesn't contain any valid certificate", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes self-signed certificate and its key in PEM files
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dione test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	notPEM := filepath.Join(dir, "not.pem")
+	require.NoError(t, ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600))
+
+	tlsConfig, err := NewTLSConfig(&config.TLSConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig, "default TLS settings are used without options")
+
+	tlsConfig, err = NewTLSConfig(&config.TLSConfig{ServerName: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", tlsConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Empty(t, tlsConfig.Certificates)
+	assert.Nil(t, tlsConfig.RootCAs)
+
+	tlsConfig, err = NewTLSConfig(&config.TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: certFile})
+	require.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.NotNil(t, tlsConfig.RootCAs)
+
+	invalid := map[string]*config.TLSConfig{
+		"certificate without key": {CertFile: certFile},
+		"key without certificate": {KeyFile: keyFile},
+		"swapped key pair":        {CertFile: keyFile, KeyFile: certFile},
+		"missing CA bundle":       {CAFile: filepath.Join(dir, "missing.pem")},
+		"CA bundle without certs": {CAFile: notPEM},
+	}
+	for name, cfg := range invalid {
+		_, err := NewTLSConfig(cfg)
+		assert.Error(t, err, name)
+	}
+}
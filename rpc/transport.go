@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/gorilla/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpproxy"
+	"golang.org/x/net/proxy"
+	"golang.org/x/xerrors"
+)
+
+// Transport contains settings of outbound connections to the data source.
+// Nil transport means direct connections with default TLS settings.
+type Transport struct {
+	TLS   *tls.Config
+	Proxy *url.URL
+}
+
+// NewTransport builds the transport of data source from its TLS options and node-wide outbound proxy.
+// Supported proxy schemes are http, socks5 and socks5h. With socks5 hostnames are resolved locally,
+// socks5h resolves them on the proxy side, as Tor requires. Credentials are taken from the userinfo of proxy url.
+func NewTransport(tlsCfg *config.TLSConfig, proxyURL string) (*Transport, error) {
+	tlsConfig, err := NewTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transport{TLS: tlsConfig}
+
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid proxy url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "socks5", "socks5h":
+		default:
+			return nil, xerrors.Errorf("unsupported proxy scheme: %s", u.Scheme)
+		}
+		t.Proxy = u
+	}
+
+	return t, nil
+}
+
+func (t *Transport) FasthttpClient() *fasthttp.Client {
+	c := &fasthttp.Client{}
+	if t == nil {
+		return c
+	}
+	c.TLSConfig = t.TLS
+	if t.Proxy != nil {
+		switch t.Proxy.Scheme {
+		case "socks5", "socks5h":
+			dial := t.socksDialer()
+			c.Dial = func(addr string) (net.Conn, error) {
+				return dial(context.Background(), "tcp", addr)
+			}
+		default:
+			proxy := t.Proxy.Host
+			if t.Proxy.User != nil {
+				proxy = t.Proxy.User.String() + "@" + proxy
+			}
+			c.Dial = fasthttpproxy.FasthttpHTTPDialer(proxy)
+		}
+	}
+	return c
+}
+
+func (t *Transport) HTTPClient() *http.Client {
+	if t == nil {
+		return &http.Client{}
+	}
+	tr := &http.Transport{TLSClientConfig: t.TLS, Proxy: http.ProxyFromEnvironment}
+	if t.isSocks() {
+		// net/http resolves hostnames on the proxy side for both socks schemes
+		tr.Proxy = nil
+		tr.DialContext = t.socksDialer()
+	} else if t.Proxy != nil {
+		tr.Proxy = http.ProxyURL(t.Proxy)
+	}
+	return &http.Client{Transport: tr}
+}
+
+func (t *Transport) WebsocketDialer() websocket.Dialer {
+	d := websocket.Dialer{Proxy: http.ProxyFromEnvironment}
+	if t == nil {
+		return d
+	}
+	d.TLSClientConfig = t.TLS
+	if t.isSocks() {
+		d.Proxy = nil
+		d.NetDialContext = t.socksDialer()
+	} else if t.Proxy != nil {
+		d.Proxy = http.ProxyURL(t.Proxy)
+	}
+	return d
+}
+
+func (t *Transport) isSocks() bool {
+	return t.Proxy != nil && (t.Proxy.Scheme == "socks5" || t.Proxy.Scheme == "socks5h")
+}
+
+// socksDialer returns the function dialing through the socks proxy of the transport
+func (t *Transport) socksDialer() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if t.Proxy.User != nil {
+		password, _ := t.Proxy.User.Password()
+		auth = &proxy.Auth{User: t.Proxy.User.Username(), Password: password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", t.Proxy.Host, auth, proxy.Direct)
+	resolveLocally := t.Proxy.Scheme == "socks5"
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create socks dialer: %w", err)
+		}
+		if resolveLocally {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			if net.ParseIP(host) == nil {
+				ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+				if err != nil {
+					return nil, err
+				}
+				if len(ips) == 0 {
+					return nil, xerrors.Errorf("no addresses of %s", host)
+				}
+				addr = net.JoinHostPort(ips[0].IP.String(), port)
+			}
+		}
+		if cd, ok := dialer.(proxy.ContextDialer); ok {
+			return cd.DialContext(ctx, network, addr)
+		}
+		return dialer.Dial(network, addr)
+	}
+}
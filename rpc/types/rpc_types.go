@@ -5,4 +5,5 @@ const (
 
 	RPCTypeFilecoin
 	RPCTypeSolana
+	RPCTypeDrand
 )
@@ -46,6 +46,10 @@ func (ed25519Signer) Verify(sig []byte, a []byte, msg []byte) error {
 	if err != nil {
 		return err
 	}
+	// ed25519.Verify panics on keys of other types
+	if len(pKeyRaw) != ed25519.PublicKeySize {
+		return xerrors.Errorf("signer's key isn't an ed25519 key")
+	}
 
 	if valid := ed25519.Verify(pKeyRaw, msg, sig); !valid {
 		return xerrors.Errorf("failed to verify signature")
@@ -0,0 +1,101 @@
+// Package sortition verifies that the miner of the task was legitimately elected to propose it.
+// It depends only on the task itself and stakes, so it can be used by light clients holding
+// the validator set without running the node.
+package sortition
+
+import (
+	"encoding/binary"
+
+	"github.com/Secured-Finance/dione/sigs"
+	_ "github.com/Secured-Finance/dione/sigs/ed25519" // enable ed25519 signatures
+	"github.com/Secured-Finance/dione/types"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/minio/blake2b-simd"
+	"golang.org/x/xerrors"
+)
+
+// DrawRandomness derives the VRF input from the beacon entry, domain separation tag, round and entropy
+func DrawRandomness(rbase []byte, pers crypto.DomainSeparationTag, round types.DrandRound, entropy []byte) ([]byte, error) {
+	h := blake2b.New256()
+	if err := binary.Write(h, binary.BigEndian, int64(pers)); err != nil {
+		return nil, xerrors.Errorf("deriving randomness: %v", err)
+	}
+	VRFDigest := blake2b.Sum256(rbase)
+	_, err := h.Write(VRFDigest[:])
+	if err != nil {
+		return nil, xerrors.Errorf("hashing VRFDigest: %w", err)
+	}
+	if err := binary.Write(h, binary.BigEndian, round); err != nil {
+		return nil, xerrors.Errorf("deriving randomness: %v", err)
+	}
+	_, err = h.Write(entropy)
+	if err != nil {
+		return nil, xerrors.Errorf("hashing entropy: %v", err)
+	}
+
+	return h.Sum(nil), nil
+}
+
+func VerifyVRF(worker peer.ID, vrfBase, vrfproof []byte) error {
+	err := sigs.Verify(&types.Signature{Type: types.SigTypeEd25519, Data: vrfproof}, []byte(worker), vrfBase)
+	if err != nil {
+		return xerrors.Errorf("vrf was invalid: %w", err)
+	}
+
+	return nil
+}
+
+// Verify checks the election proof and ticket of the task: both VRFs must be produced by the task miner
+// from the task beacon entry, and the win count must match the miner stake. Beacon entries themselves
+// aren't verified here, the caller must check them against the drand chain.
+func Verify(task *types.DioneTask, minerStake, networkStake types.BigInt) error {
+	if task.ElectionProof == nil || task.Ticket == nil {
+		return xerrors.Errorf("task has no election proof or ticket")
+	}
+	if len(task.BeaconEntries) != 2 {
+		return xerrors.Errorf("task has %d beacon entries instead of 2", len(task.BeaconEntries))
+	}
+	if task.DrandRound <= types.TicketRandomnessLookback || uint64(task.DrandRound) != task.BeaconEntries[1].Round {
+		return xerrors.Errorf("task drand round %d doesn't match its beacon entries", task.DrandRound)
+	}
+	if task.ElectionProof.WinCount < 1 {
+		return xerrors.Errorf("miner isn't a winner")
+	}
+
+	minerAddressMarshalled, err := task.Miner.MarshalBinary()
+	if err != nil {
+		return xerrors.Errorf("failed to marshal miner address: %w", err)
+	}
+
+	electionProofRandomness, err := DrawRandomness(
+		task.BeaconEntries[1].Data,
+		crypto.DomainSeparationTag_ElectionProofProduction,
+		task.DrandRound,
+		minerAddressMarshalled,
+	)
+	if err != nil {
+		return xerrors.Errorf("failed to draw election proof randomness: %w", err)
+	}
+	if err := VerifyVRF(task.Miner, electionProofRandomness, task.ElectionProof.VRFProof); err != nil {
+		return xerrors.Errorf("failed to verify election proof vrf: %w", err)
+	}
+
+	ticketRandomness, err := DrawRandomness(
+		task.BeaconEntries[1].Data,
+		crypto.DomainSeparationTag_TicketProduction,
+		task.DrandRound-types.TicketRandomnessLookback,
+		minerAddressMarshalled,
+	)
+	if err != nil {
+		return xerrors.Errorf("failed to draw ticket randomness: %w", err)
+	}
+	if err := VerifyVRF(task.Miner, ticketRandomness, task.Ticket.VRFProof); err != nil {
+		return xerrors.Errorf("failed to verify ticket vrf: %w", err)
+	}
+
+	if winCount := task.ElectionProof.ComputeWinCount(minerStake, networkStake); winCount != task.ElectionProof.WinCount {
+		return xerrors.Errorf("win count %d doesn't match locally computed %d", task.ElectionProof.WinCount, winCount)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package sortition
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/Secured-Finance/dione/sigs"
+	"github.com/Secured-Finance/dione/types"
+	"github.com/filecoin-project/go-state-types/crypto"
+	p2pcrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func newElectedTask(t *testing.T, stake, totalStake types.BigInt) *types.DioneTask {
+	seed := make([]byte, ed25519.SeedSize)
+	privKey := ed25519.NewKeyFromSeed(seed)
+	pubKey, err := p2pcrypto.UnmarshalEd25519PublicKey(privKey.Public().(ed25519.PublicKey))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	miner, err := peer.IDFromPublicKey(pubKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	minerBytes, _ := miner.MarshalBinary()
+
+	task := &types.DioneTask{
+		Miner:         miner,
+		BeaconEntries: []types.BeaconEntry{{Round: 9, Data: []byte("prev")}, {Round: 10, Data: []byte("beacon")}},
+		DrandRound:    10,
+	}
+	vrf := func(tag crypto.DomainSeparationTag, round types.DrandRound) []byte {
+		input, err := DrawRandomness(task.BeaconEntries[1].Data, tag, round, minerBytes)
+		assert.NoError(t, err)
+		sig, err := sigs.Sign(types.SigTypeEd25519, privKey, input)
+		assert.NoError(t, err)
+		return sig.Data
+	}
+	task.Ticket = &types.Ticket{VRFProof: vrf(crypto.DomainSeparationTag_TicketProduction, task.DrandRound-types.TicketRandomnessLookback)}
+	task.ElectionProof = &types.ElectionProof{VRFProof: vrf(crypto.DomainSeparationTag_ElectionProofProduction, task.DrandRound)}
+	task.ElectionProof.WinCount = task.ElectionProof.ComputeWinCount(stake, totalStake)
+	return task
+}
+
+func TestVerify(t *testing.T) {
+	stake := types.NewInt(100)
+	task := newElectedTask(t, stake, stake)
+	if !assert.True(t, task.ElectionProof.WinCount > 0, "miner holding the whole stake must win") {
+		return
+	}
+	assert.NoError(t, Verify(task, stake, stake))
+
+	// win count doesn't match the stake
+	assert.Error(t, Verify(task, types.NewInt(1), types.NewInt(1000000)))
+
+	tampered := *task
+	tampered.BeaconEntries = []types.BeaconEntry{task.BeaconEntries[0], {Round: 10, Data: []byte("other beacon")}}
+	assert.Error(t, Verify(&tampered, stake, stake))
+
+	tampered = *task
+	tampered.Ticket = &types.Ticket{VRFProof: task.ElectionProof.VRFProof}
+	assert.Error(t, Verify(&tampered, stake, stake))
+
+	tampered = *task
+	tampered.ElectionProof = nil
+	assert.Error(t, Verify(&tampered, stake, stake))
+
+	tampered = *task
+	tampered.DrandRound = 11
+	assert.Error(t, Verify(&tampered, stake, stake))
+}
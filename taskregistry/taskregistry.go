@@ -0,0 +1,109 @@
+package taskregistry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/Secured-Finance/dione/lib"
+	"golang.org/x/xerrors"
+)
+
+// RetentionBlocks is the count of blocks the registry remembers requests for
+const RetentionBlocks = 100000
+
+type registryState struct {
+	LastBlock uint64            `json:"last_block"`
+	Requests  map[string]uint64 `json:"requests"` // request ID -> block number
+}
+
+// Registry keeps track of oracle requests the node has already picked up from the source chain
+// and the last synced block, so requests emitted while the node was offline can be caught up
+// and no request is processed twice.
+type Registry struct {
+	path  string
+	mutex sync.Mutex
+	state registryState
+}
+
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path:  path,
+		state: registryState{Requests: map[string]uint64{}},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, xerrors.Errorf("failed to read task registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.state); err != nil {
+		return nil, xerrors.Errorf("failed to decode task registry: %w", err)
+	}
+	if r.state.Requests == nil {
+		r.state.Requests = map[string]uint64{}
+	}
+
+	return r, nil
+}
+
+// Add registers the request emitted at specified block, it returns false if the request is already known.
+// It doesn't move the last synced block: live events may arrive before the blocks preceding them are synced,
+// so only SetLastBlock called after syncing a block range does it.
+func (r *Registry) Add(requestID string, block uint64) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.state.Requests[requestID]; ok {
+		return false, nil
+	}
+	r.state.Requests[requestID] = block
+	return true, r.save()
+}
+
+// LastBlock returns the number of the last block synced from the source chain
+func (r *Registry) LastBlock() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.state.LastBlock
+}
+
+// SetLastBlock marks all blocks up to specified one as synced
+func (r *Registry) SetLastBlock(block uint64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if block <= r.state.LastBlock {
+		return nil
+	}
+	r.state.LastBlock = block
+	r.prune()
+	return r.save()
+}
+
+func (r *Registry) prune() {
+	if r.state.LastBlock <= RetentionBlocks {
+		return
+	}
+	minBlock := r.state.LastBlock - RetentionBlocks
+	for id, block := range r.state.Requests {
+		if block < minBlock {
+			delete(r.state.Requests, id)
+		}
+	}
+}
+
+func (r *Registry) save() error {
+	data, err := json.Marshal(&r.state)
+	if err != nil {
+		return xerrors.Errorf("failed to encode task registry: %w", err)
+	}
+
+	if err := lib.WriteFileAtomic(r.path, data, 0600); err != nil {
+		return xerrors.Errorf("failed to write task registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package taskregistry
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+
+	r, err := NewRegistry(path)
+	assert.NoError(t, err)
+	isNew, err := r.Add("1", 10)
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+	assert.NoError(t, r.SetLastBlock(20))
+	assert.NoError(t, r.SetLastBlock(15))
+
+	r, err = NewRegistry(path)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), r.LastBlock())
+	isNew, err = r.Add("1", 10)
+	assert.NoError(t, err)
+	assert.False(t, isNew)
+
+	// live requests don't mark the blocks before them as synced
+	isNew, err = r.Add("2", 30)
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, uint64(20), r.LastBlock())
+
+	// old requests are forgotten once they fall out of retention window
+	assert.NoError(t, r.SetLastBlock(RetentionBlocks+11))
+	isNew, err = r.Add("1", 10)
+	assert.NoError(t, err)
+	assert.True(t, isNew)
+}
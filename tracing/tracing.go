@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/Secured-Finance/dione/config"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/exporters/trace/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+)
+
+const (
+	ExporterJaeger = "jaeger"
+	ExporterOTLP   = "otlp"
+
+	tracerName = "github.com/Secured-Finance/dione"
+)
+
+// Init sets up global tracer provider with the exporter specified in config.
+// If no exporter is configured, spans are not recorded at all.
+// Returned function flushes pending spans and must be called on node shutdown.
+func Init(ctx context.Context, cfg *config.TracingConfig) (func(context.Context) error, error) {
+	var exporter exporttrace.SpanExporter
+	switch cfg.Exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterJaeger:
+		exp, err := jaeger.NewRawExporter(
+			jaeger.WithCollectorEndpoint(cfg.Endpoint),
+			jaeger.WithProcess(jaeger.Process{ServiceName: cfg.ServiceName}),
+		)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create jaeger exporter: %w", err)
+		}
+		exporter = exp
+	case ExporterOTLP:
+		exp, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(
+			otlpgrpc.WithInsecure(),
+			otlpgrpc.WithEndpoint(cfg.Endpoint),
+		))
+		if err != nil {
+			return nil, xerrors.Errorf("failed to create otlp exporter: %w", err)
+		}
+		exporter = exp
+	default:
+		return nil, xerrors.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// StartSpan starts a new span as a child of the span contained in ctx (if any)
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError marks the span as failed with specified error
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying the ID of oracle request being processed. The ID is assigned
+// by the oracle contract, so it's the same on every node and correlates logs of the request across nodes.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID of oracle request carried by ctx or empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// TraceID returns the ID of the trace the span of ctx belongs to or empty string if ctx isn't traced
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID.String()
+}
+
+// Logger returns the log entry with request and trace IDs of ctx as fields
+func Logger(ctx context.Context) *logrus.Entry {
+	fields := logrus.Fields{}
+	if id := RequestID(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if id := TraceID(ctx); id != "" {
+		fields["trace_id"] = id
+	}
+	return logrus.WithFields(fields)
+}
+
+// maxCarrierSize limits the trace context received from peers
+const maxCarrierSize = 8
+
+type mapCarrier map[string]string
+
+func (c mapCarrier) Get(key string) string { return c[key] }
+
+func (c mapCarrier) Set(key, value string) { c[key] = value }
+
+func (c mapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Inject encodes the trace context of ctx to be sent to other nodes, it returns nil if tracing is disabled
+func Inject(ctx context.Context) map[string]string {
+	c := mapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, c)
+	if len(c) == 0 {
+		return nil
+	}
+	return c
+}
+
+// Extract returns ctx continuing the trace received from another node, so spans of all nodes
+// taking part in the consensus round are joined into a single trace
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 || len(carrier) > maxCarrierSize {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, mapCarrier(carrier))
+}
@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestTracePropagation(t *testing.T) {
+	assert.Nil(t, Inject(context.Background()))
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	ctx := WithRequestID(context.Background(), "42")
+	ctx, span := StartSpan(ctx, "oracle_request")
+	defer span.End()
+
+	carrier := Inject(ctx)
+	assert.NotEmpty(t, carrier)
+
+	// the receiving node continues the trace of the sender
+	remote, remoteSpan := StartSpan(Extract(context.Background(), carrier), "consensus")
+	defer remoteSpan.End()
+	assert.NotEmpty(t, TraceID(ctx))
+	assert.Equal(t, TraceID(ctx), TraceID(remote))
+
+	entry := Logger(ctx)
+	assert.Equal(t, "42", entry.Data["request_id"])
+	assert.Equal(t, TraceID(ctx), entry.Data["trace_id"])
+	assert.Empty(t, Logger(context.Background()).Data)
+}
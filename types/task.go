@@ -29,6 +29,8 @@ type DioneTask struct {
 	BeaconEntries []BeaconEntry
 	DrandRound    DrandRound
 	Payload       []byte
+	FetchedAt     int64 // unix time when the payload was fetched from the data source
+	Deadline      int64 // unix time after which the task can't be agreed or submitted
 	RequestID     string
 	ConsensusID   string
 	Signature     []byte `hash:"-"`